@@ -15,17 +15,38 @@
 package go_sms_sender
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/aliyun/alibaba-cloud-sdk-go/sdk/errors"
+	"github.com/aliyun/alibaba-cloud-sdk-go/sdk/requests"
+	"github.com/aliyun/alibaba-cloud-sdk-go/sdk/responses"
 	"github.com/aliyun/alibaba-cloud-sdk-go/services/dysmsapi"
+	"github.com/casdoor/go-sms-sender/internal/providerdata"
+	"github.com/casdoor/go-sms-sender/phone"
 )
 
+const (
+	aliyunRegion     = "cn-hangzhou"
+	aliyunIntlDomain = "dysmsapi.ap-southeast-1.aliyuncs.com"
+)
+
+// AliyunClient sends through the mainland SendSms API for "cn-" regions. For
+// other regions, e.g. ap-southeast-1, it uses SendMessageToGlobe of the
+// international site, then template is the message text as for Twilio.
 type AliyunClient struct {
 	template string
 	sign     string
 	core     *dysmsapi.Client
+	endpoint string
+	intl     bool
+	text     *MessageTemplate
+	segments SegmentPolicy
 }
 
 type AliyunResult struct {
@@ -33,9 +54,62 @@ type AliyunResult struct {
 	Message   string
 }
 
-func GetAliyunClient(accessId string, accessKey string, sign string, template string) (*AliyunClient, error) {
-	region := "cn-hangzhou"
-	client, err := dysmsapi.NewClientWithAccessKey(region, accessId, accessKey)
+// AliyunGlobeResult is the SendMessageToGlobe response, code OK means success.
+type AliyunGlobeResult struct {
+	RequestId           string
+	ResponseCode        string
+	ResponseDescription string
+	MessageId           string
+	Segments            json.Number
+	To                  string
+}
+
+// aliyunErrors maps the error codes of the Aliyun SendSms API.
+var aliyunErrors = map[string]error{
+	"isv.ACCOUNT_NOT_EXISTS":          ErrInvalidCredentials,
+	"isv.ACCOUNT_ABNORMAL":            ErrInvalidCredentials,
+	"isp.RAM_PERMISSION_DENY":         ErrInvalidCredentials,
+	"InvalidAccessKeyId.NotFound":     ErrInvalidCredentials,
+	"SignatureDoesNotMatch":           ErrInvalidCredentials,
+	"isv.MOBILE_NUMBER_ILLEGAL":       ErrInvalidNumber,
+	"isv.MOBILE_COUNT_OVER_LIMIT":     ErrInvalidNumber,
+	"isv.BUSINESS_LIMIT_CONTROL":      ErrRateLimited,
+	"isv.DAY_LIMIT_CONTROL":           ErrRateLimited,
+	"Throttling.User":                 ErrRateLimited,
+	"isv.SMS_SIGNATURE_ILLEGAL":       ErrContentRejected,
+	"isv.SMS_TEMPLATE_ILLEGAL":        ErrContentRejected,
+	"isv.TEMPLATE_PARAMS_ILLEGAL":     ErrContentRejected,
+	"isv.PARAM_LENGTH_LIMIT":          ErrContentRejected,
+	"isv.BLACK_KEY_CONTROL_LIMIT":     ErrContentRejected,
+	"isv.AMOUNT_NOT_ENOUGH":           ErrInsufficientBalance,
+	"isv.OUT_OF_SERVICE":              ErrInsufficientBalance,
+	"isv.SYSTEM_ERROR":                ErrProviderUnavailable,
+	"isp.SYSTEM_ERROR":                ErrProviderUnavailable,
+	"isv.MISSING_PARAMETER":           ErrMissingParameter,
+	"isv.TEMPLATE_MISSING_PARAMETERS": ErrMissingParameter,
+	"isv.INVALID_PARAMETERS":          ErrMissingParameter,
+}
+
+func init() {
+	Register(Aliyun, func(config *Config) (SmsClient, error) {
+		return GetAliyunClient(config.AccessId, config.AccessKey, config.Sign, config.Template, config.Region)
+	},
+		requiredField("accessId", "Access Key ID"),
+		requiredField("accessKey", "Access Key Secret"),
+		requiredField("sign", "Sign Name"),
+		requiredField("template", "Template Code"),
+		otherField("region", "Region", false),
+		optionalField("endpoint", "Endpoint"),
+	)
+}
+
+func GetAliyunClient(accessId string, accessKey string, sign string, template string, region ...string) (*AliyunClient, error) {
+	regionId := aliyunRegion
+	if len(region) > 0 && region[0] != "" {
+		regionId = region[0]
+	}
+
+	client, err := dysmsapi.NewClientWithAccessKey(regionId, accessId, accessKey)
 	if err != nil {
 		return nil, err
 	}
@@ -44,44 +118,249 @@ func GetAliyunClient(accessId string, accessKey string, sign string, template st
 		template: template,
 		core:     client,
 		sign:     sign,
+		intl:     !strings.HasPrefix(regionId, "cn-"),
+		text:     NewMessageTemplate(template),
 	}
 
 	return aliyunClient, nil
 }
 
+func (c *AliyunClient) SetHttpClient(httpClient *http.Client) {
+	c.core.SetTransport(transportOf(httpClient))
+}
+
+func (c *AliyunClient) SetEndpoint(endpoint string) {
+	c.endpoint = endpoint
+}
+
+// SetTemplate sets the message text sent through the international site, the
+// mainland API keeps using the template code.
+func (c *AliyunClient) SetTemplate(template *MessageTemplate) {
+	c.text = template
+}
+
+func (c *AliyunClient) SetSegmentPolicy(policy SegmentPolicy) {
+	c.segments = policy
+}
+
 func (c *AliyunClient) SendMessage(param map[string]string, targetPhoneNumber ...string) error {
+	return c.SendMessageContext(context.Background(), param, targetPhoneNumber...)
+}
+
+func (c *AliyunClient) SendMessageContext(ctx context.Context, param map[string]string, targetPhoneNumber ...string) error {
+	_, err := c.SendMessageResult(ctx, param, targetPhoneNumber...)
+	return err
+}
+
+func (c *AliyunClient) SendMessageResult(ctx context.Context, param map[string]string, targetPhoneNumber ...string) (*SendResult, error) {
 	requestParam, err := json.Marshal(param)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if len(targetPhoneNumber) == 0 {
-		return fmt.Errorf("missing parameter: targetPhoneNumber")
+		return nil, missingParameterError("targetPhoneNumber")
+	}
+
+	if c.intl {
+		return c.sendMessageToGlobe(ctx, param, targetPhoneNumber)
 	}
 
 	request := dysmsapi.CreateSendSmsRequest()
 	request.Scheme = "https"
+	if c.endpoint != "" {
+		request.Scheme, request.Domain = splitEndpoint(c.endpoint)
+	}
 	request.PhoneNumbers = strings.Join(targetPhoneNumber, ",")
 	request.TemplateCode = c.template
 	request.TemplateParam = string(requestParam)
 	request.SignName = c.sign
 
-	response, err := c.core.SendSms(request)
-	if err != nil {
+	var response *dysmsapi.SendSmsResponse
+	err = runWithContext(ctx, func() error {
+		var err error
+		response, err = c.core.SendSms(request)
 		return err
+	})
+	if err != nil {
+		if serverErr, ok := err.(*errors.ServerError); ok {
+			return nil, newSmsError(Aliyun, serverErr.ErrorCode(), serverErr.Message(), aliyunErrors)
+		}
+		return nil, err
+	}
+
+	result := newSendResult(Aliyun)
+	result.RequestId = response.RequestId
+
+	status := SendStatusAccepted
+	if response.Code != "OK" {
+		status = SendStatusRejected
+	}
+	for _, phoneNumber := range targetPhoneNumber {
+		recipient := result.add(phoneNumber, response.BizId, status)
+		recipient.Code = response.Code
+		recipient.Message = response.Message
 	}
 
 	if response.Code != "OK" {
 		aliyunResult := AliyunResult{}
 		err = json.Unmarshal(response.GetHttpContentBytes(), &aliyunResult)
 		if err != nil {
+			return result, err
+		}
+
+		return result, newSmsError(Aliyun, response.Code, aliyunResult.Message, aliyunErrors)
+	}
+
+	return result, nil
+}
+
+// sendMessageToGlobe sends to numbers outside mainland China through the international site.
+func (c *AliyunClient) sendMessageToGlobe(ctx context.Context, param map[string]string, targetPhoneNumber []string) (*SendResult, error) {
+	bodies, err := renderBodies(c.text, c.segments, param, targetPhoneNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	result := newSendResult(Aliyun)
+	for i, phoneNumber := range targetPhoneNumber {
+		number, parseErr := phone.Parse(phoneNumber, "")
+		if parseErr != nil || number.CountryCode == "86" {
+			err := &SmsError{Provider: Aliyun, Message: "mainland China numbers require a cn- region", Kind: ErrInvalidNumber}
+			if parseErr != nil {
+				err.Message = parseErr.Error()
+			}
+			result.add(phoneNumber, "", SendStatusRejected).Message = err.Message
+			return result, err
+		}
+		to := number.Format(phone.Digits)
+
+		request := requests.NewCommonRequest()
+		request.Method = "POST"
+		request.Scheme = "https"
+		request.Domain = aliyunIntlDomain
+		if c.endpoint != "" {
+			request.Scheme, request.Domain = splitEndpoint(c.endpoint)
+		}
+		request.Version = "2018-05-01"
+		request.ApiName = "SendMessageToGlobe"
+		request.QueryParams["To"] = to
+		request.QueryParams["Message"] = bodies[i]
+		if c.sign != "" {
+			request.QueryParams["From"] = c.sign
+		}
+
+		var response *responses.CommonResponse
+		err := runWithContext(ctx, func() error {
+			var err error
+			response, err = c.core.ProcessCommonRequest(request)
 			return err
+		})
+		if err != nil {
+			if serverErr, ok := err.(*errors.ServerError); ok {
+				err = newSmsError(Aliyun, serverErr.ErrorCode(), serverErr.Message(), aliyunErrors)
+			}
+			result.add(phoneNumber, "", SendStatusRejected).Message = err.Error()
+			return result, err
 		}
 
-		if aliyunResult.Message != "" {
-			return fmt.Errorf(aliyunResult.Message)
+		globeResult := AliyunGlobeResult{}
+		err = json.Unmarshal(response.GetHttpContentBytes(), &globeResult)
+		if err != nil {
+			return result, err
 		}
+
+		if result.RequestId == "" {
+			result.RequestId = globeResult.RequestId
+		}
+
+		if globeResult.ResponseCode != "OK" {
+			recipient := result.add(phoneNumber, "", SendStatusRejected)
+			recipient.Code = globeResult.ResponseCode
+			recipient.Message = globeResult.ResponseDescription
+			return result, newSmsError(Aliyun, globeResult.ResponseCode, globeResult.ResponseDescription, aliyunErrors)
+		}
+
+		recipient := result.add(phoneNumber, globeResult.MessageId, SendStatusAccepted)
+		recipient.Code = globeResult.ResponseCode
+		recipient.Segments, _ = strconv.Atoi(globeResult.Segments.String())
+	}
+
+	return result, nil
+}
+
+// QueryStatus looks the message up with QuerySendDetails, which needs the
+// phone number and the day it was sent, today unless query.SentAt is set.
+// Only the mainland SendSms API supports it.
+func (c *AliyunClient) QueryStatus(ctx context.Context, query StatusQuery) (*DeliveryReport, error) {
+	if c.intl {
+		return nil, fmt.Errorf("provider %s does not support status queries outside cn- regions", Aliyun)
+	}
+	if query.MessageId == "" || query.PhoneNumber == "" {
+		return nil, missingParameterError("messageId or phoneNumber")
+	}
+
+	sentAt := query.SentAt
+	if sentAt.IsZero() {
+		sentAt = time.Now()
+	}
+	phoneNumber := query.PhoneNumber
+	if number, err := phone.Parse(phoneNumber, "CN"); err == nil && number.CountryCode == "86" {
+		phoneNumber = number.Format(phone.National)
+	}
+
+	request := dysmsapi.CreateQuerySendDetailsRequest()
+	request.Scheme = "https"
+	if c.endpoint != "" {
+		request.Scheme, request.Domain = splitEndpoint(c.endpoint)
+	}
+	request.PhoneNumber = phoneNumber
+	request.SendDate = sentAt.In(providerdata.ChinaTime).Format("20060102")
+	request.BizId = query.MessageId
+	request.PageSize = requests.NewInteger(10)
+	request.CurrentPage = requests.NewInteger(1)
+
+	var response *dysmsapi.QuerySendDetailsResponse
+	err := runWithContext(ctx, func() error {
+		var err error
+		response, err = c.core.QuerySendDetails(request)
+		return err
+	})
+	if err != nil {
+		if serverErr, ok := err.(*errors.ServerError); ok {
+			return nil, newSmsError(Aliyun, serverErr.ErrorCode(), serverErr.Message(), aliyunErrors)
+		}
+		return nil, err
+	}
+	if response.Code != "OK" {
+		return nil, newSmsError(Aliyun, response.Code, response.Message, aliyunErrors)
+	}
+
+	details := response.SmsSendDetailDTOs.SmsSendDetailDTO
+	if len(details) == 0 {
+		return nil, ErrMessageNotFound
+	}
+
+	// SendStatus is 1 while waiting for the receipt, 2 on failure and 3 on delivery.
+	detail := details[0]
+	report := &DeliveryReport{
+		Provider:  Aliyun,
+		MessageId: query.MessageId,
+		Recipient: detail.PhoneNum,
+		Status:    DeliveryStatusUnknown,
+	}
+	switch detail.SendStatus {
+	case 1:
+		report.Status = DeliveryStatusPending
+	case 2:
+		report.Status = DeliveryStatusFailed
+		report.ErrorCode = detail.ErrCode
+	case 3:
+		report.Status = DeliveryStatusDelivered
+	}
+	if receivedAt, err := time.ParseInLocation("2006-01-02 15:04:05", detail.ReceiveDate, providerdata.ChinaTime); err == nil {
+		report.Timestamp = receivedAt
 	}
 
-	return nil
+	return report, nil
 }
@@ -15,9 +15,11 @@
 package go_sms_sender
 
 import (
-	"fmt"
+	"context"
+	"net/http"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/sns"
@@ -25,13 +27,41 @@ import (
 )
 
 type AmazonSNSClient struct {
+	sess     *session.Session
+	config   *aws.Config
 	svc      snsiface.SNSAPI
-	template string
+	template *MessageTemplate
+	segments SegmentPolicy
+}
+
+// snsErrors maps the SNS Publish error codes.
+var snsErrors = map[string]error{
+	"AuthorizationError":    ErrInvalidCredentials,
+	"InvalidClientTokenId":  ErrInvalidCredentials,
+	"SignatureDoesNotMatch": ErrInvalidCredentials,
+	"Throttling":            ErrRateLimited,
+	"ThrottlingException":   ErrRateLimited,
+	"ThrottledException":    ErrRateLimited,
+	"InvalidParameter":      ErrInvalidNumber,
+	"InternalError":         ErrProviderUnavailable,
+	"ServiceUnavailable":    ErrProviderUnavailable,
+}
+
+func init() {
+	Register(AmazonSNS, func(config *Config) (SmsClient, error) {
+		return GetAmazonSNSClient(config.AccessId, config.AccessKey, config.Template, []string{config.Region})
+	},
+		requiredField("accessId", "Access Key ID"),
+		requiredField("accessKey", "Secret Access Key"),
+		requiredField("template", "Template"),
+		otherField("region", "Region", true),
+		optionalField("endpoint", "Endpoint"),
+	)
 }
 
 func GetAmazonSNSClient(accessKeyID string, secretAccessKey string, template string, region []string) (*AmazonSNSClient, error) {
 	if len(region) == 0 {
-		return nil, fmt.Errorf("missing parameter: region")
+		return nil, missingParameterError("region")
 	}
 
 	sess, err := session.NewSession(&aws.Config{
@@ -45,23 +75,50 @@ func GetAmazonSNSClient(accessKeyID string, secretAccessKey string, template str
 	svc := sns.New(sess)
 
 	snsClient := &AmazonSNSClient{
+		sess:     sess,
+		config:   aws.NewConfig(),
 		svc:      svc,
-		template: template,
+		template: NewMessageTemplate(template),
 	}
 
 	return snsClient, nil
 }
 
+func (a *AmazonSNSClient) SetHttpClient(httpClient *http.Client) {
+	a.config.WithHTTPClient(httpClient)
+	a.svc = sns.New(a.sess, a.config)
+}
+
+func (a *AmazonSNSClient) SetTemplate(template *MessageTemplate) {
+	a.template = template
+}
+
+func (a *AmazonSNSClient) SetSegmentPolicy(policy SegmentPolicy) {
+	a.segments = policy
+}
+
+func (a *AmazonSNSClient) SetEndpoint(endpoint string) {
+	a.config.WithEndpoint(endpoint)
+	a.svc = sns.New(a.sess, a.config)
+}
+
 func (a *AmazonSNSClient) SendMessage(param map[string]string, targetPhoneNumber ...string) error {
-	code, ok := param["code"]
-	if !ok {
-		return fmt.Errorf("missing parameter: code")
-	}
+	return a.SendMessageContext(context.Background(), param, targetPhoneNumber...)
+}
 
-	bodyContent := fmt.Sprintf(a.template, code)
+func (a *AmazonSNSClient) SendMessageContext(ctx context.Context, param map[string]string, targetPhoneNumber ...string) error {
+	_, err := a.SendMessageResult(ctx, param, targetPhoneNumber...)
+	return err
+}
 
+func (a *AmazonSNSClient) SendMessageResult(ctx context.Context, param map[string]string, targetPhoneNumber ...string) (*SendResult, error) {
 	if len(targetPhoneNumber) == 0 {
-		return fmt.Errorf("missing parameter: targetPhoneNumber")
+		return nil, missingParameterError("targetPhoneNumber")
+	}
+
+	bodies, err := renderBodies(a.template, a.segments, param, targetPhoneNumber)
+	if err != nil {
+		return nil, err
 	}
 
 	messageAttributes := make(map[string]*sns.MessageAttributeValue)
@@ -72,16 +129,23 @@ func (a *AmazonSNSClient) SendMessage(param map[string]string, targetPhoneNumber
 		}
 	}
 
+	result := newSendResult(AmazonSNS)
 	for i := 0; i < len(targetPhoneNumber); i++ {
-		_, err := a.svc.Publish(&sns.PublishInput{
-			Message:           &bodyContent,
+		output, err := a.svc.PublishWithContext(ctx, &sns.PublishInput{
+			Message:           &bodies[i],
 			PhoneNumber:       &targetPhoneNumber[i],
 			MessageAttributes: messageAttributes,
 		})
 		if err != nil {
-			return err
+			if awsErr, ok := err.(awserr.Error); ok {
+				err = newSmsError(AmazonSNS, awsErr.Code(), awsErr.Message(), snsErrors)
+			}
+			result.add(targetPhoneNumber[i], "", SendStatusRejected).Message = err.Error()
+			return result, err
 		}
+
+		result.add(targetPhoneNumber[i], aws.StringValue(output.MessageId), SendStatusAccepted)
 	}
 
-	return nil
+	return result, nil
 }
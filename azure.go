@@ -16,9 +16,12 @@ package go_sms_sender
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 )
 
 type ACSClient struct {
@@ -26,6 +29,9 @@ type ACSClient struct {
 	Endpoint    string
 	Message     string
 	Sender      string
+
+	segments   SegmentPolicy
+	httpClient *http.Client
 }
 
 type reqBody struct {
@@ -38,9 +44,38 @@ type smsRecipient struct {
 	To string `json:"to"`
 }
 
+type ACSSendResponse struct {
+	Value []ACSSendResult `json:"value"`
+	Error *ACSError       `json:"error"`
+}
+
+type ACSSendResult struct {
+	To             string `json:"to"`
+	MessageId      string `json:"messageId"`
+	HttpStatusCode int    `json:"httpStatusCode"`
+	Successful     bool   `json:"successful"`
+	ErrorMessage   string `json:"errorMessage"`
+}
+
+type ACSError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func init() {
+	Register(AzureACS, func(config *Config) (SmsClient, error) {
+		return GetACSClient(config.AccessKey, config.Template, []string{config.Endpoint, config.Sender})
+	},
+		requiredField("accessKey", "Access Token"),
+		requiredField("template", "Message"),
+		otherField("endpoint", "Endpoint", true),
+		otherField("sender", "Sender", true),
+	)
+}
+
 func GetACSClient(accessToken string, message string, other []string) (*ACSClient, error) {
 	if len(other) < 2 {
-		return nil, fmt.Errorf("missing parameter: endpoint or sender")
+		return nil, missingParameterError("endpoint or sender")
 	}
 
 	acsClient := &ACSClient{
@@ -48,19 +83,46 @@ func GetACSClient(accessToken string, message string, other []string) (*ACSClien
 		Endpoint:    other[0],
 		Message:     message,
 		Sender:      other[1],
+		httpClient:  newDefaultHttpClient(),
 	}
 
 	return acsClient, nil
 }
 
+func (a *ACSClient) SetHttpClient(httpClient *http.Client) {
+	a.httpClient = httpClient
+}
+
+func (a *ACSClient) SetSegmentPolicy(policy SegmentPolicy) {
+	a.segments = policy
+}
+
+func (a *ACSClient) SetEndpoint(endpoint string) {
+	a.Endpoint = strings.TrimSuffix(endpoint, "/")
+}
+
 func (a *ACSClient) SendMessage(param map[string]string, targetPhoneNumber ...string) error {
+	return a.SendMessageContext(context.Background(), param, targetPhoneNumber...)
+}
+
+func (a *ACSClient) SendMessageContext(ctx context.Context, param map[string]string, targetPhoneNumber ...string) error {
+	_, err := a.SendMessageResult(ctx, param, targetPhoneNumber...)
+	return err
+}
+
+func (a *ACSClient) SendMessageResult(ctx context.Context, param map[string]string, targetPhoneNumber ...string) (*SendResult, error) {
 	if len(targetPhoneNumber) == 0 {
-		return fmt.Errorf("missing parameter: targetPhoneNumber")
+		return nil, missingParameterError("targetPhoneNumber")
+	}
+
+	message, err := a.segments.Apply(a.Message)
+	if err != nil {
+		return nil, err
 	}
 
 	reqBody := &reqBody{
 		From:          a.Sender,
-		Message:       a.Message,
+		Message:       message,
 		SMSRecipients: make([]smsRecipient, 0),
 	}
 	for _, mobile := range targetPhoneNumber {
@@ -69,27 +131,59 @@ func (a *ACSClient) SendMessage(param map[string]string, targetPhoneNumber ...st
 
 	url := fmt.Sprintf("%s/sms?api-version=2021-03-07", a.Endpoint)
 
-	client := &http.Client{}
-
 	requestBody, err := json.Marshal(reqBody)
 	if err != nil {
-		return fmt.Errorf("error creating request body: %w", err)
+		return nil, fmt.Errorf("error creating request body: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(requestBody))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(requestBody))
 	if err != nil {
-		return fmt.Errorf("error creating request: %w", err)
+		return nil, fmt.Errorf("error creating request: %w", err)
 	}
 
 	req.Header.Add("Authorization", "Bearer "+a.AccessToken)
 	req.Header.Add("Content-Type", "application/json")
 
-	resp, err := client.Do(req)
+	resp, err := httpClientOrDefault(a.httpClient).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error sending request: %w", err)
+	}
+
+	body, err := readResponse(resp)
 	if err != nil {
-		return fmt.Errorf("error sending request: %w", err)
+		return nil, fmt.Errorf("error reading response: %w", err)
 	}
 
-	resp.Body.Close()
+	var acsResponse ACSSendResponse
+	err = json.Unmarshal(body, &acsResponse)
+
+	if statusErr := checkHttpStatus(AzureACS, resp, body); statusErr != nil {
+		if acsResponse.Error != nil {
+			statusErr.(*SmsError).Message = fmt.Sprintf("%s: %s", acsResponse.Error.Code, acsResponse.Error.Message)
+		}
+		return nil, statusErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error parsing response: %w", err)
+	}
+
+	result := newSendResult(AzureACS)
+	errMsgs := []string{}
+	for _, item := range acsResponse.Value {
+		status := SendStatusAccepted
+		if !item.Successful {
+			status = SendStatusRejected
+			errMsgs = append(errMsgs, fmt.Sprintf("%s: %s", item.To, item.ErrorMessage))
+		}
+
+		recipient := result.add(item.To, item.MessageId, status)
+		recipient.Code = strconv.Itoa(item.HttpStatusCode)
+		recipient.Message = item.ErrorMessage
+	}
+
+	if len(errMsgs) > 0 {
+		return result, &SmsError{Provider: AzureACS, Message: strings.Join(errMsgs, "|")}
+	}
 
-	return nil
+	return result, nil
 }
@@ -15,6 +15,7 @@
 package go_sms_sender
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
@@ -28,9 +29,21 @@ type BaiduClient struct {
 	core     *sms.Client
 }
 
+func init() {
+	Register(BaiduCloud, func(config *Config) (SmsClient, error) {
+		return GetBceClient(config.AccessId, config.AccessKey, config.Sign, config.Template, []string{config.Endpoint})
+	},
+		requiredField("accessId", "Access Key ID"),
+		requiredField("accessKey", "Secret Access Key"),
+		requiredField("sign", "Signature ID"),
+		requiredField("template", "Template"),
+		otherField("endpoint", "Endpoint", true),
+	)
+}
+
 func GetBceClient(accessId, accessKey, sign, template string, endpoint []string) (*BaiduClient, error) {
 	if len(endpoint) == 0 {
-		return nil, fmt.Errorf("missing parameter: endpoint")
+		return nil, missingParameterError("endpoint")
 	}
 
 	client, err := sms.NewClient(accessId, accessKey, endpoint[0])
@@ -47,14 +60,27 @@ func GetBceClient(accessId, accessKey, sign, template string, endpoint []string)
 	return bceClient, nil
 }
 
+func (c *BaiduClient) SetEndpoint(endpoint string) {
+	c.core.Config.Endpoint = endpoint
+}
+
 func (c *BaiduClient) SendMessage(param map[string]string, targetPhoneNumber ...string) error {
+	return c.SendMessageContext(context.Background(), param, targetPhoneNumber...)
+}
+
+func (c *BaiduClient) SendMessageContext(ctx context.Context, param map[string]string, targetPhoneNumber ...string) error {
+	_, err := c.SendMessageResult(ctx, param, targetPhoneNumber...)
+	return err
+}
+
+func (c *BaiduClient) SendMessageResult(ctx context.Context, param map[string]string, targetPhoneNumber ...string) (*SendResult, error) {
 	code, ok := param["code"]
 	if !ok {
-		return fmt.Errorf("missing parameter: code")
+		return nil, missingParameterError("code")
 	}
 
 	if len(targetPhoneNumber) == 0 {
-		return fmt.Errorf("missing parameter: targetPhoneNumber")
+		return nil, missingParameterError("targetPhoneNumber")
 	}
 
 	contentMap := make(map[string]interface{})
@@ -67,10 +93,38 @@ func (c *BaiduClient) SendMessage(param map[string]string, targetPhoneNumber ...
 		ContentVar:  contentMap,
 	}
 
-	_, err := c.core.SendSms(sendSmsArgs)
-	if err != nil {
+	var response *api.SendSmsResult
+	err := runWithContext(ctx, func() error {
+		var err error
+		response, err = c.core.SendSms(sendSmsArgs)
 		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := newSendResult(BaiduCloud)
+	result.RequestId = response.RequestId
+	errMsgs := []string{}
+	errCode := ""
+	for _, item := range response.Data {
+		status := SendStatusAccepted
+		if item.Code != "1000" {
+			status = SendStatusRejected
+			errMsgs = append(errMsgs, fmt.Sprintf("%s: %s", item.Mobile, item.Message))
+			if errCode == "" {
+				errCode = item.Code
+			}
+		}
+
+		recipient := result.add(item.Mobile, item.MessageId, status)
+		recipient.Code = item.Code
+		recipient.Message = item.Message
+	}
+
+	if len(errMsgs) > 0 {
+		return result, &SmsError{Provider: BaiduCloud, Code: errCode, Message: strings.Join(errMsgs, "|")}
 	}
 
-	return nil
+	return result, nil
 }
@@ -0,0 +1,150 @@
+// Copyright 2024 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_sms_sender
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Balance is the credit left on a provider account. Amount is in Currency,
+// which is empty for the providers counting messages only, and
+// RemainingMessages is -1 for the providers reporting money only.
+type Balance struct {
+	Provider          string
+	Amount            float64
+	Currency          string
+	RemainingMessages int
+}
+
+// BalanceChecker is implemented by the clients that can query the balance of
+// their account.
+type BalanceChecker interface {
+	QueryBalance(ctx context.Context) (*Balance, error)
+}
+
+// QueryBalance queries the balance of the account of client.
+func QueryBalance(ctx context.Context, client SmsClient) (*Balance, error) {
+	checker, ok := client.(BalanceChecker)
+	if !ok {
+		return nil, fmt.Errorf("client %T does not support balance queries", client)
+	}
+
+	return checker.QueryBalance(ctx)
+}
+
+func newMessageBalance(provider string, remainingMessages int) *Balance {
+	return &Balance{Provider: provider, RemainingMessages: remainingMessages}
+}
+
+func newAmountBalance(provider string, amount float64, currency string) *Balance {
+	return &Balance{Provider: provider, Amount: amount, Currency: currency, RemainingMessages: -1}
+}
+
+// parseMessageCount parses a message count, which some providers report with
+// decimals.
+func parseMessageCount(s string) (int, error) {
+	count, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return 0, err
+	}
+	return int(count), nil
+}
+
+// BalanceAlertPolicy configures a BalanceMonitor. A balance is low if Amount
+// is below MinAmount or RemainingMessages below MinMessages, zero thresholds
+// are not checked. Interval is the period of Run, 10 minutes by default.
+type BalanceAlertPolicy struct {
+	MinAmount   float64
+	MinMessages int
+	Interval    time.Duration
+	// OnLow is called when the balance becomes low, and again only after it
+	// was topped up above the thresholds.
+	OnLow func(ctx context.Context, balance *Balance)
+	// OnError is called with the errors of the queries made by Run.
+	OnError func(ctx context.Context, err error)
+}
+
+// BalanceMonitor checks the balance of a client against the thresholds of
+// its policy, to alert before sends start failing.
+type BalanceMonitor struct {
+	client SmsClient
+	policy BalanceAlertPolicy
+
+	mu  sync.Mutex
+	low bool
+}
+
+// NewBalanceMonitor returns a monitor of the balance of client, which must be
+// a BalanceChecker. Wrappers over several providers, like FailoverClient, are
+// not, so each provider client needs its own monitor.
+func NewBalanceMonitor(client SmsClient, policy BalanceAlertPolicy) *BalanceMonitor {
+	if policy.Interval <= 0 {
+		policy.Interval = 10 * time.Minute
+	}
+
+	return &BalanceMonitor{
+		client: client,
+		policy: policy,
+	}
+}
+
+// Check queries the balance and calls OnLow if it became low.
+func (m *BalanceMonitor) Check(ctx context.Context) (*Balance, error) {
+	balance, err := QueryBalance(ctx, m.client)
+	if err != nil {
+		return nil, err
+	}
+
+	low := m.isLow(balance)
+	m.mu.Lock()
+	alert := low && !m.low
+	m.low = low
+	m.mu.Unlock()
+
+	if alert && m.policy.OnLow != nil {
+		m.policy.OnLow(ctx, balance)
+	}
+	return balance, nil
+}
+
+// Run checks the balance every Interval until ctx is done.
+func (m *BalanceMonitor) Run(ctx context.Context) error {
+	ticker := time.NewTicker(m.policy.Interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := m.Check(ctx); err != nil && m.policy.OnError != nil && ctx.Err() == nil {
+			m.policy.OnError(ctx, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (m *BalanceMonitor) isLow(balance *Balance) bool {
+	if m.policy.MinAmount > 0 && balance.Currency != "" && balance.Amount < m.policy.MinAmount {
+		return true
+	}
+	return m.policy.MinMessages > 0 && balance.RemainingMessages >= 0 && balance.RemainingMessages < m.policy.MinMessages
+}
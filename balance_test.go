@@ -0,0 +1,112 @@
+// Copyright 2024 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_sms_sender
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+// balanceClient is a fakeClient whose balance queries answer amounts in turn,
+// repeating the last one.
+type balanceClient struct {
+	fakeClient
+	amounts []float64
+	queries int
+}
+
+func (c *balanceClient) QueryBalance(ctx context.Context) (*Balance, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	amount := 100.0
+	if len(c.amounts) > 0 {
+		amount = c.amounts[0]
+		if len(c.amounts) > 1 {
+			c.amounts = c.amounts[1:]
+		}
+	}
+	c.queries++
+	return newAmountBalance("fake", amount, "CNY"), nil
+}
+
+func TestQueryBalance(t *testing.T) {
+	tests := []struct {
+		name    string
+		client  SmsClient
+		wantErr bool
+	}{
+		{"checker", &balanceClient{}, false},
+		{"retry", NewRetryClient(&balanceClient{}, RetryPolicy{}), false},
+		{"circuit breaker", NewCircuitBreaker(&balanceClient{}, CircuitBreakerPolicy{}), false},
+		{"rate limit", NewRateLimitClient(&balanceClient{}, RateLimitPolicy{}), false},
+		{"opt-out", NewOptOutClient(&balanceClient{}, OptOutPolicy{}), false},
+		{"nested wrappers", NewRetryClient(NewCircuitBreaker(&balanceClient{}, CircuitBreakerPolicy{}), RetryPolicy{}), false},
+		{"not a checker", &fakeClient{}, true},
+		{"wrapped non checker", NewCircuitBreaker(&fakeClient{}, CircuitBreakerPolicy{}), true},
+		{"failover", NewFailoverClient(FailoverProvider{Name: "fake", Client: &balanceClient{}}), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			balance, err := QueryBalance(context.Background(), tt.client)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %t", err, tt.wantErr)
+			}
+			if !tt.wantErr && (balance.Amount != 100 || balance.Currency != "CNY" || balance.RemainingMessages != -1) {
+				t.Errorf("balance = %+v, want 100 CNY", balance)
+			}
+		})
+	}
+}
+
+func TestCircuitBreakerQueryBalanceWhileOpen(t *testing.T) {
+	client := &balanceClient{}
+	breaker := NewCircuitBreaker(client, CircuitBreakerPolicy{FailureThreshold: 1})
+	breaker.record(errUnavailable, false)
+	if breaker.State() != CircuitOpen {
+		t.Fatalf("state = %v, want open", breaker.State())
+	}
+
+	if _, err := breaker.QueryBalance(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if client.queries != 1 {
+		t.Errorf("queries = %d, want 1", client.queries)
+	}
+}
+
+func TestBalanceMonitorCheck(t *testing.T) {
+	client := &balanceClient{amounts: []float64{100, 8, 5, 50, 3}}
+	var alerts []float64
+	monitor := NewBalanceMonitor(NewRetryClient(client, RetryPolicy{}), BalanceAlertPolicy{
+		MinAmount: 10,
+		OnLow: func(ctx context.Context, balance *Balance) {
+			alerts = append(alerts, balance.Amount)
+		},
+	})
+
+	for i := 0; i < 5; i++ {
+		if _, err := monitor.Check(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// low once until topped up, then low again
+	if want := []float64{8, 3}; !reflect.DeepEqual(alerts, want) {
+		t.Errorf("alerts = %v, want %v", alerts, want)
+	}
+}
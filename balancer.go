@@ -0,0 +1,276 @@
+// Copyright 2024 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_sms_sender
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type BalanceStrategy string
+
+const (
+	// WeightedRoundRobin spreads sends over the members in proportion to their weight.
+	WeightedRoundRobin BalanceStrategy = "weighted-round-robin"
+	// LeastErrors picks the member with the lowest recent error rate.
+	LeastErrors BalanceStrategy = "least-errors"
+	// LowestLatency picks the member with the lowest recent latency of successful
+	// sends, members without a successful send are only picked while none has one.
+	LowestLatency BalanceStrategy = "lowest-latency"
+)
+
+const (
+	defaultEjectAfter    = 3
+	defaultEjectDuration = 30 * time.Second
+
+	// balancerDecay is the weight of the latest send in the error rate and latency averages.
+	balancerDecay = 0.2
+)
+
+// BalancerPolicy configures a BalancingClient. Zero values use weighted
+// round-robin and eject a member for 30s after 3 consecutive failures.
+type BalancerPolicy struct {
+	Strategy      BalanceStrategy
+	EjectAfter    int
+	EjectDuration time.Duration
+}
+
+// BalancerMember is one client of a BalancingClient, Weight defaults to 1.
+type BalancerMember struct {
+	Name   string
+	Client SmsClient
+	Weight int
+}
+
+// BalancerMemberStatus is the health of a member as tracked by a BalancingClient.
+type BalancerMemberStatus struct {
+	Name      string
+	Weight    int
+	Ejected   bool
+	ErrorRate float64
+	Latency   time.Duration
+}
+
+type balancerMember struct {
+	BalancerMember
+	currentWeight       int
+	errorRate           float64
+	latency             time.Duration
+	consecutiveFailures int
+	ejectedUntil        time.Time
+}
+
+// BalancingClient distributes sends over a pool of clients with a
+// BalanceStrategy. Members failing EjectAfter times in a row are left out for
+// EjectDuration, unless every member is ejected, and so are members whose
+// client is not available, see AvailabilityReporter. Errors caused by the
+// message itself, see IsPermanent, do not count against a member.
+type BalancingClient struct {
+	mu      sync.Mutex
+	members []*balancerMember
+	policy  BalancerPolicy
+}
+
+var _ ResultSmsClient = &BalancingClient{}
+
+func NewBalancingClient(policy BalancerPolicy, members ...BalancerMember) *BalancingClient {
+	if policy.Strategy == "" {
+		policy.Strategy = WeightedRoundRobin
+	}
+	if policy.EjectAfter <= 0 {
+		policy.EjectAfter = defaultEjectAfter
+	}
+	if policy.EjectDuration <= 0 {
+		policy.EjectDuration = defaultEjectDuration
+	}
+
+	balancingClient := &BalancingClient{
+		policy: policy,
+	}
+	for _, member := range members {
+		if member.Weight <= 0 {
+			member.Weight = 1
+		}
+		balancingClient.members = append(balancingClient.members, &balancerMember{BalancerMember: member})
+	}
+
+	return balancingClient
+}
+
+func (c *BalancingClient) SendMessage(param map[string]string, targetPhoneNumber ...string) error {
+	return c.SendMessageContext(context.Background(), param, targetPhoneNumber...)
+}
+
+func (c *BalancingClient) SendMessageContext(ctx context.Context, param map[string]string, targetPhoneNumber ...string) error {
+	_, err := c.SendMessageResult(ctx, param, targetPhoneNumber...)
+	return err
+}
+
+func (c *BalancingClient) SendMessageResult(ctx context.Context, param map[string]string, targetPhoneNumber ...string) (*SendResult, error) {
+	member := c.pick()
+	if member == nil {
+		return nil, missingParameterError("members")
+	}
+
+	start := time.Now()
+	result, err := SendMessageWithResult(ctx, member.Client, param, targetPhoneNumber...)
+	c.record(member, time.Since(start), err)
+
+	if result != nil && member.Name != "" {
+		for i := range result.Recipients {
+			if result.Recipients[i].Provider == "" {
+				result.Recipients[i].Provider = member.Name
+			}
+		}
+	}
+
+	return result, err
+}
+
+// Members returns the health of every member, e.g. for a health check endpoint.
+func (c *BalancingClient) Members() []BalancerMemberStatus {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	statuses := make([]BalancerMemberStatus, 0, len(c.members))
+	for _, member := range c.members {
+		statuses = append(statuses, BalancerMemberStatus{
+			Name:      member.Name,
+			Weight:    member.Weight,
+			Ejected:   now.Before(member.ejectedUntil) || !isAvailable(member.Client),
+			ErrorRate: member.errorRate,
+			Latency:   member.latency,
+		})
+	}
+	return statuses
+}
+
+func (c *BalancingClient) pick() *balancerMember {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	candidates := []*balancerMember{}
+	for _, member := range c.members {
+		if !now.Before(member.ejectedUntil) && isAvailable(member.Client) {
+			candidates = append(candidates, member)
+		}
+	}
+	if len(candidates) == 0 {
+		candidates = c.members
+	}
+
+	switch c.policy.Strategy {
+	case LeastErrors:
+		candidates = lowestScore(candidates, func(member *balancerMember) float64 {
+			return member.errorRate
+		})
+	case LowestLatency:
+		candidates = lowestScore(measured(candidates), func(member *balancerMember) float64 {
+			return float64(member.latency)
+		})
+	}
+
+	return weightedRoundRobin(candidates)
+}
+
+func (c *BalancingClient) record(member *balancerMember, latency time.Duration, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	failed := err != nil && !IsPermanent(err)
+
+	failure := 0.0
+	if failed {
+		failure = 1
+	}
+	member.errorRate += balancerDecay * (failure - member.errorRate)
+
+	// failures are often fast and would make a failing provider look fastest
+	if err == nil {
+		if member.latency == 0 {
+			member.latency = latency
+		} else {
+			member.latency += time.Duration(balancerDecay * float64(latency-member.latency))
+		}
+	}
+
+	if !failed {
+		member.consecutiveFailures = 0
+		return
+	}
+
+	member.consecutiveFailures++
+	if member.consecutiveFailures >= c.policy.EjectAfter {
+		member.consecutiveFailures = 0
+		member.ejectedUntil = time.Now().Add(c.policy.EjectDuration)
+	}
+}
+
+func isAvailable(client SmsClient) bool {
+	reporter, ok := client.(AvailabilityReporter)
+	return !ok || reporter.Available()
+}
+
+// measured returns the members with a latency, which only successful sends
+// give, or all of them if none has one yet. A member whose sends keep failing
+// would otherwise look fastest.
+func measured(members []*balancerMember) []*balancerMember {
+	result := []*balancerMember{}
+	for _, member := range members {
+		if member.latency > 0 {
+			result = append(result, member)
+		}
+	}
+	if len(result) == 0 {
+		return members
+	}
+	return result
+}
+
+// lowestScore returns the members sharing the lowest score.
+func lowestScore(members []*balancerMember, score func(member *balancerMember) float64) []*balancerMember {
+	best := []*balancerMember{}
+	for _, member := range members {
+		switch {
+		case len(best) == 0 || score(member) < score(best[0]):
+			best = []*balancerMember{member}
+		case score(member) == score(best[0]):
+			best = append(best, member)
+		}
+	}
+	return best
+}
+
+// weightedRoundRobin is the smooth weighted round-robin used by nginx, which
+// interleaves the members instead of sending bursts to the heaviest one.
+func weightedRoundRobin(members []*balancerMember) *balancerMember {
+	var selected *balancerMember
+	total := 0
+	for _, member := range members {
+		member.currentWeight += member.Weight
+		total += member.Weight
+		if selected == nil || member.currentWeight > selected.currentWeight {
+			selected = member
+		}
+	}
+
+	if selected != nil {
+		selected.currentWeight -= total
+	}
+	return selected
+}
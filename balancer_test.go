@@ -0,0 +1,133 @@
+// Copyright 2024 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_sms_sender
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+// failingReplies scripts n sends failing with err.
+func failingReplies(n int, err error) []fakeReply {
+	replies := make([]fakeReply, n)
+	for i := range replies {
+		replies[i] = fakeReply{noResult: true, err: err}
+	}
+	return replies
+}
+
+func TestBalancingClientStrategies(t *testing.T) {
+	tests := []struct {
+		name      string
+		policy    BalancerPolicy
+		weights   []int
+		replies   [][]fakeReply
+		wantCalls []int
+	}{
+		{
+			name:      "weighted round robin",
+			weights:   []int{2, 1},
+			replies:   [][]fakeReply{nil, nil},
+			wantCalls: []int{4, 2},
+		},
+		{
+			name:      "least errors",
+			policy:    BalancerPolicy{Strategy: LeastErrors, EjectAfter: 100},
+			weights:   []int{1, 1},
+			replies:   [][]fakeReply{failingReplies(6, errUnavailable), nil},
+			wantCalls: []int{1, 5},
+		},
+		{
+			name:      "lowest latency ignores members without a successful send",
+			policy:    BalancerPolicy{Strategy: LowestLatency, EjectAfter: 100},
+			weights:   []int{1, 1},
+			replies:   [][]fakeReply{failingReplies(6, errUnavailable), nil},
+			wantCalls: []int{1, 5},
+		},
+		{
+			name:      "failing member is ejected",
+			policy:    BalancerPolicy{EjectAfter: 2},
+			weights:   []int{1, 1},
+			replies:   [][]fakeReply{failingReplies(6, errUnavailable), nil},
+			wantCalls: []int{2, 4},
+		},
+		{
+			name:      "permanent errors do not eject",
+			policy:    BalancerPolicy{EjectAfter: 2},
+			weights:   []int{1, 1},
+			replies:   [][]fakeReply{failingReplies(6, errBadNumber), nil},
+			wantCalls: []int{3, 3},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clients := []*fakeClient{}
+			members := []BalancerMember{}
+			for i, weight := range tt.weights {
+				client := &fakeClient{replies: tt.replies[i]}
+				clients = append(clients, client)
+				members = append(members, BalancerMember{Client: client, Weight: weight})
+			}
+			balancer := NewBalancingClient(tt.policy, members...)
+
+			for i := 0; i < 6; i++ {
+				_ = balancer.SendMessageContext(context.Background(), nil, "+8613800138000")
+			}
+
+			calls := []int{}
+			for _, client := range clients {
+				calls = append(calls, len(client.calls))
+			}
+			if !reflect.DeepEqual(calls, tt.wantCalls) {
+				t.Errorf("calls = %v, want %v", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestBalancingClientMembers(t *testing.T) {
+	failing := &fakeClient{replies: failingReplies(2, errUnavailable)}
+	balancer := NewBalancingClient(BalancerPolicy{EjectAfter: 1},
+		BalancerMember{Name: "failing", Client: failing},
+		BalancerMember{Name: "down", Client: &unavailableClient{}},
+		BalancerMember{Name: "working", Client: &fakeClient{}},
+	)
+
+	result, err := balancer.SendMessageResult(context.Background(), nil, "+8613800138000")
+	if err == nil {
+		t.Fatal("first send succeeded, want the error of the failing member")
+	}
+	if result != nil {
+		t.Errorf("result = %v, want nil", result)
+	}
+
+	result, err = balancer.SendMessageResult(context.Background(), nil, "+8613800138000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := recipientSummary(result); !reflect.DeepEqual(got, []string{"+8613800138000 working accepted"}) {
+		t.Errorf("recipients = %v", got)
+	}
+
+	ejected := map[string]bool{}
+	for _, member := range balancer.Members() {
+		ejected[member.Name] = member.Ejected
+	}
+	if want := map[string]bool{"failing": true, "down": true, "working": false}; !reflect.DeepEqual(ejected, want) {
+		t.Errorf("ejected = %v, want %v", ejected, want)
+	}
+}
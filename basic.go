@@ -14,7 +14,7 @@
 
 package go_sms_sender
 
-import "fmt"
+import "context"
 
 const (
 	Twilio       = "Twilio SMS"
@@ -42,47 +42,53 @@ type SmsClient interface {
 	SendMessage(param map[string]string, targetPhoneNumber ...string) error
 }
 
+// ContextSmsClient is a SmsClient whose sends can be cancelled or bounded by a context.
+type ContextSmsClient interface {
+	SmsClient
+	SendMessageContext(ctx context.Context, param map[string]string, targetPhoneNumber ...string) error
+}
+
+// SendMessageWithContext sends the message with ctx if the client supports it,
+// otherwise it checks ctx once and falls back to SendMessage.
+func SendMessageWithContext(ctx context.Context, client SmsClient, param map[string]string, targetPhoneNumber ...string) error {
+	if contextClient, ok := client.(ContextSmsClient); ok {
+		return contextClient.SendMessageContext(ctx, param, targetPhoneNumber...)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return client.SendMessage(param, targetPhoneNumber...)
+}
+
+// runWithContext runs fn for SDKs that take no context, returning early with
+// ctx.Err() when ctx is done. fn keeps running in the background in that case.
+func runWithContext(ctx context.Context, fn func() error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- fn()
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// NewSmsClient creates a client for a provider registered with Register.
+// The built-in providers are registered under the constants above.
 func NewSmsClient(provider string, accessId string, accessKey string, sign string, template string, other ...string) (SmsClient, error) {
-	switch provider {
-	case Twilio:
-		return GetTwilioClient(accessId, accessKey, template)
-	case AmazonSNS:
-		return GetAmazonSNSClient(accessId, accessKey, template, other)
-	case AzureACS:
-		return GetACSClient(accessKey, template, other)
-	case Msg91:
-		return GetMsg91Client(accessId, accessKey, template)
-	case GCCPAY:
-		return GetGCCPAYClient(accessId, accessKey, template)
-	case Infobip:
-		return GetInfobipClient(accessId, accessKey, template, other)
-	case SUBMAIL:
-		return GetSubmailClient(accessId, accessKey, template)
-	case SmsBao:
-		return GetSmsbaoClient(accessId, accessKey, sign, template, other)
-	case Aliyun:
-		return GetAliyunClient(accessId, accessKey, sign, template)
-	case TencentCloud:
-		return GetTencentClient(accessId, accessKey, sign, template, other)
-	case BaiduCloud:
-		return GetBceClient(accessId, accessKey, sign, template, other)
-	case VolcEngine:
-		return GetVolcClient(accessId, accessKey, sign, template, other)
-	case HuaweiCloud:
-		return GetHuaweiClient(accessId, accessKey, sign, template, other)
-	case UCloud:
-		return GetUcloudClient(accessId, accessKey, sign, template, other)
-	case Huyi:
-		return GetHuyiClient(accessId, accessKey, template)
-	case Netgsm:
-		return GetNetgsmClient(accessId, accessKey, sign, template)
-	case MockSms:
-		return NewMocker(accessId, accessKey, sign, template, other)
-	case OsonSms:
-		return GetOsonClient(accessId, accessKey, sign, template)
-	case UniSms:
-		return GetUnismsClient(accessId, accessKey, sign, template)
-	default:
-		return nil, fmt.Errorf("unsupported provider: %s", provider)
+	p, err := getProvider(provider)
+	if err != nil {
+		return nil, err
 	}
+
+	return NewSmsClientFromConfig(newConfig(p.info, accessId, accessKey, sign, template, other))
 }
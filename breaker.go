@@ -0,0 +1,222 @@
+// Copyright 2024 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_sms_sender
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+type CircuitState string
+
+const (
+	// CircuitClosed lets every send through.
+	CircuitClosed CircuitState = "closed"
+	// CircuitOpen fails every send until the cool-down is over.
+	CircuitOpen CircuitState = "open"
+	// CircuitHalfOpen lets one probe send through to decide whether to close again.
+	CircuitHalfOpen CircuitState = "half-open"
+)
+
+const (
+	defaultFailureThreshold = 5
+	defaultCoolDown         = 30 * time.Second
+)
+
+// ErrCircuitOpen is returned by a CircuitBreaker that does not let a send through.
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// AvailabilityReporter is implemented by clients that know they cannot send
+// right now, such as an open CircuitBreaker. FailoverClient and
+// BalancingClient skip the clients that are not available.
+type AvailabilityReporter interface {
+	Available() bool
+}
+
+// CircuitBreakerPolicy configures a CircuitBreaker. Zero values open the
+// circuit after 5 consecutive failures, for a 30s cool-down, and close it
+// after 1 successful probe.
+type CircuitBreakerPolicy struct {
+	FailureThreshold  int
+	CoolDown          time.Duration
+	HalfOpenSuccesses int
+	// OnStateChange, if set, is called after every transition, e.g. to alert or
+	// export metrics. It runs with the breaker locked and must not call it.
+	OnStateChange func(from CircuitState, to CircuitState)
+}
+
+// CircuitBreaker stops calling a client that keeps failing. Errors caused by
+// the message itself, see IsPermanent, show that the provider answered and
+// are not counted as failures, but timeouts are. Cancelled sends are ignored.
+type CircuitBreaker struct {
+	client SmsClient
+	policy CircuitBreakerPolicy
+
+	mu        sync.Mutex
+	state     CircuitState
+	failures  int
+	successes int
+	openedAt  time.Time
+	probing   bool
+}
+
+var (
+	_ ResultSmsClient      = &CircuitBreaker{}
+	_ AvailabilityReporter = &CircuitBreaker{}
+)
+
+func NewCircuitBreaker(client SmsClient, policy CircuitBreakerPolicy) *CircuitBreaker {
+	if policy.FailureThreshold <= 0 {
+		policy.FailureThreshold = defaultFailureThreshold
+	}
+	if policy.CoolDown <= 0 {
+		policy.CoolDown = defaultCoolDown
+	}
+	if policy.HalfOpenSuccesses <= 0 {
+		policy.HalfOpenSuccesses = 1
+	}
+
+	return &CircuitBreaker{
+		client: client,
+		policy: policy,
+		state:  CircuitClosed,
+	}
+}
+
+// State returns the current state, an open circuit whose cool-down is over is half-open.
+func (b *CircuitBreaker) State() CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.coolDown()
+	return b.state
+}
+
+// Available reports whether a send would be let through now.
+func (b *CircuitBreaker) Available() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.coolDown()
+	return b.state == CircuitClosed || (b.state == CircuitHalfOpen && !b.probing)
+}
+
+func (b *CircuitBreaker) SendMessage(param map[string]string, targetPhoneNumber ...string) error {
+	return b.SendMessageContext(context.Background(), param, targetPhoneNumber...)
+}
+
+func (b *CircuitBreaker) SendMessageContext(ctx context.Context, param map[string]string, targetPhoneNumber ...string) error {
+	_, err := b.SendMessageResult(ctx, param, targetPhoneNumber...)
+	return err
+}
+
+func (b *CircuitBreaker) SendMessageResult(ctx context.Context, param map[string]string, targetPhoneNumber ...string) (*SendResult, error) {
+	probe, err := b.allow()
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := SendMessageWithResult(ctx, b.client, param, targetPhoneNumber...)
+	b.record(err, probe)
+	return result, err
+}
+
+// QueryBalance queries the balance of the client whatever the circuit state.
+func (b *CircuitBreaker) QueryBalance(ctx context.Context) (*Balance, error) {
+	return QueryBalance(ctx, b.client)
+}
+
+// QueryStatus queries the delivery status through the client whatever the
+// circuit state.
+func (b *CircuitBreaker) QueryStatus(ctx context.Context, query StatusQuery) (*DeliveryReport, error) {
+	return QueryStatus(ctx, b.client, query)
+}
+
+// allow reports whether a send may go through, and whether it is the probe
+// of a half-open circuit.
+func (b *CircuitBreaker) allow() (probe bool, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.coolDown()
+	switch b.state {
+	case CircuitOpen:
+		return false, ErrCircuitOpen
+	case CircuitHalfOpen:
+		if b.probing {
+			return false, ErrCircuitOpen
+		}
+		b.probing = true
+		return true, nil
+	}
+	return false, nil
+}
+
+// record counts the outcome of a send let through by allow. Sends that started
+// before the circuit opened and end after it are ignored, only the probe
+// decides whether a half-open circuit closes.
+func (b *CircuitBreaker) record(err error, probe bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if probe {
+		b.probing = false
+	} else if b.state != CircuitClosed {
+		return
+	}
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) || (err != nil && !IsPermanent(err)) {
+		b.successes = 0
+		b.failures++
+		if b.state == CircuitHalfOpen || b.failures >= b.policy.FailureThreshold {
+			b.setState(CircuitOpen)
+		}
+		return
+	}
+
+	b.failures = 0
+	if b.state == CircuitHalfOpen {
+		b.successes++
+		if b.successes >= b.policy.HalfOpenSuccesses {
+			b.setState(CircuitClosed)
+		}
+	}
+}
+
+// coolDown moves an open circuit to half-open once the cool-down is over.
+func (b *CircuitBreaker) coolDown() {
+	if b.state == CircuitOpen && time.Since(b.openedAt) >= b.policy.CoolDown {
+		b.setState(CircuitHalfOpen)
+	}
+}
+
+func (b *CircuitBreaker) setState(state CircuitState) {
+	from := b.state
+	b.state = state
+	b.failures = 0
+	b.successes = 0
+	if state == CircuitOpen {
+		b.openedAt = time.Now()
+	}
+
+	if b.policy.OnStateChange != nil && from != state {
+		b.policy.OnStateChange(from, state)
+	}
+}
@@ -0,0 +1,217 @@
+// Copyright 2024 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_sms_sender
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// breakerStep is a send through a CircuitBreaker, after waiting for the
+// cool-down if coolDown is set.
+type breakerStep struct {
+	coolDown  bool
+	reply     fakeReply
+	wantErr   error
+	wantState CircuitState
+}
+
+func TestCircuitBreakerStateChanges(t *testing.T) {
+	fail := fakeReply{noResult: true, err: errUnavailable}
+	ok := fakeReply{}
+	tests := []struct {
+		name            string
+		policy          CircuitBreakerPolicy
+		steps           []breakerStep
+		wantTransitions []string
+	}{
+		{
+			name:   "opens after consecutive failures",
+			policy: CircuitBreakerPolicy{FailureThreshold: 2},
+			steps: []breakerStep{
+				{reply: fail, wantErr: ErrProviderUnavailable, wantState: CircuitClosed},
+				{reply: fail, wantErr: ErrProviderUnavailable, wantState: CircuitOpen},
+				{reply: ok, wantErr: ErrCircuitOpen, wantState: CircuitOpen},
+			},
+			wantTransitions: []string{"closed>open"},
+		},
+		{
+			name:   "success resets failures",
+			policy: CircuitBreakerPolicy{FailureThreshold: 2},
+			steps: []breakerStep{
+				{reply: fail, wantErr: ErrProviderUnavailable, wantState: CircuitClosed},
+				{reply: ok, wantState: CircuitClosed},
+				{reply: fail, wantErr: ErrProviderUnavailable, wantState: CircuitClosed},
+			},
+		},
+		{
+			name:   "permanent errors are not failures",
+			policy: CircuitBreakerPolicy{FailureThreshold: 2},
+			steps: []breakerStep{
+				{reply: fakeReply{noResult: true, err: errBadNumber}, wantErr: ErrInvalidNumber, wantState: CircuitClosed},
+				{reply: fakeReply{noResult: true, err: errBadNumber}, wantErr: ErrInvalidNumber, wantState: CircuitClosed},
+			},
+		},
+		{
+			name:   "timeouts are failures",
+			policy: CircuitBreakerPolicy{FailureThreshold: 1},
+			steps: []breakerStep{
+				{reply: fakeReply{noResult: true, err: context.DeadlineExceeded}, wantErr: context.DeadlineExceeded, wantState: CircuitOpen},
+			},
+			wantTransitions: []string{"closed>open"},
+		},
+		{
+			name:   "cancelled sends are ignored",
+			policy: CircuitBreakerPolicy{FailureThreshold: 1},
+			steps: []breakerStep{
+				{reply: fakeReply{noResult: true, err: context.Canceled}, wantErr: context.Canceled, wantState: CircuitClosed},
+			},
+		},
+		{
+			name:   "successful probe closes",
+			policy: CircuitBreakerPolicy{FailureThreshold: 1},
+			steps: []breakerStep{
+				{reply: fail, wantErr: ErrProviderUnavailable, wantState: CircuitOpen},
+				{coolDown: true, reply: ok, wantState: CircuitClosed},
+			},
+			wantTransitions: []string{"closed>open", "open>half-open", "half-open>closed"},
+		},
+		{
+			name:   "failed probe reopens",
+			policy: CircuitBreakerPolicy{FailureThreshold: 2},
+			steps: []breakerStep{
+				{reply: fail, wantErr: ErrProviderUnavailable, wantState: CircuitClosed},
+				{reply: fail, wantErr: ErrProviderUnavailable, wantState: CircuitOpen},
+				{coolDown: true, reply: fail, wantErr: ErrProviderUnavailable, wantState: CircuitOpen},
+				{reply: ok, wantErr: ErrCircuitOpen, wantState: CircuitOpen},
+			},
+			wantTransitions: []string{"closed>open", "open>half-open", "half-open>open"},
+		},
+		{
+			name:   "several probes",
+			policy: CircuitBreakerPolicy{FailureThreshold: 1, HalfOpenSuccesses: 2},
+			steps: []breakerStep{
+				{reply: fail, wantErr: ErrProviderUnavailable, wantState: CircuitOpen},
+				{coolDown: true, reply: ok, wantState: CircuitHalfOpen},
+				{reply: ok, wantState: CircuitClosed},
+			},
+			wantTransitions: []string{"closed>open", "open>half-open", "half-open>closed"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transitions := []string{}
+			policy := tt.policy
+			policy.CoolDown = 10 * time.Millisecond
+			policy.OnStateChange = func(from CircuitState, to CircuitState) {
+				transitions = append(transitions, string(from)+">"+string(to))
+			}
+			client := &fakeClient{}
+			breaker := NewCircuitBreaker(client, policy)
+
+			for i, step := range tt.steps {
+				if step.coolDown {
+					time.Sleep(policy.CoolDown)
+				}
+
+				client.replies = []fakeReply{step.reply}
+				_, err := breaker.SendMessageResult(context.Background(), nil, "+8613800138000")
+				if !errors.Is(err, step.wantErr) || (err != nil) != (step.wantErr != nil) {
+					t.Fatalf("step %d: error = %v, want %v", i+1, err, step.wantErr)
+				}
+				if state := breaker.State(); state != step.wantState {
+					t.Fatalf("step %d: state = %s, want %s", i+1, state, step.wantState)
+				}
+			}
+
+			if len(tt.wantTransitions) == 0 {
+				tt.wantTransitions = []string{}
+			}
+			if !reflect.DeepEqual(transitions, tt.wantTransitions) {
+				t.Errorf("transitions = %v, want %v", transitions, tt.wantTransitions)
+			}
+		})
+	}
+}
+
+func TestCircuitBreakerSingleProbe(t *testing.T) {
+	breaker := NewCircuitBreaker(&fakeClient{}, CircuitBreakerPolicy{FailureThreshold: 1, CoolDown: time.Millisecond})
+	breaker.record(errUnavailable, false)
+	if breaker.Available() {
+		t.Fatal("open circuit is available")
+	}
+
+	time.Sleep(time.Millisecond)
+	if !breaker.Available() {
+		t.Fatal("half-open circuit is not available")
+	}
+	probe, err := breaker.allow()
+	if err != nil || !probe {
+		t.Fatalf("allow() = %v, %v, want the probe", probe, err)
+	}
+	if breaker.Available() {
+		t.Error("half-open circuit is available during its probe")
+	}
+	if _, err := breaker.allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("second probe error = %v, want ErrCircuitOpen", err)
+	}
+}
+
+func TestCircuitBreakerSlowSendDuringProbe(t *testing.T) {
+	tests := []struct {
+		name      string
+		slowErr   error
+		wantState CircuitState
+	}{
+		{name: "slow success", wantState: CircuitHalfOpen},
+		{name: "slow failure", slowErr: errUnavailable, wantState: CircuitHalfOpen},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			breaker := NewCircuitBreaker(&fakeClient{}, CircuitBreakerPolicy{FailureThreshold: 1, CoolDown: time.Millisecond})
+
+			// a send let through while closed is still running when the
+			// circuit opens, cools down and starts its probe
+			slow, err := breaker.allow()
+			if err != nil {
+				t.Fatal(err)
+			}
+			breaker.record(errUnavailable, false)
+			time.Sleep(time.Millisecond)
+			probe, err := breaker.allow()
+			if err != nil || !probe {
+				t.Fatalf("allow() = %v, %v, want the probe", probe, err)
+			}
+
+			breaker.record(tt.slowErr, slow)
+			if state := breaker.State(); state != tt.wantState {
+				t.Errorf("state after the slow send = %v, want %v", state, tt.wantState)
+			}
+			if breaker.Available() {
+				t.Error("slow send ended the probe")
+			}
+
+			breaker.record(nil, probe)
+			if state := breaker.State(); state != CircuitClosed {
+				t.Errorf("state after the probe = %v, want %v", state, CircuitClosed)
+			}
+		})
+	}
+}
@@ -0,0 +1,219 @@
+// Copyright 2024 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_sms_sender
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Config is the named configuration of a client. Only the fields listed by the
+// provider in ListProviders are used, Extra holds the fields of providers
+// registered outside this package.
+type Config struct {
+	Provider   string            `json:"provider" yaml:"provider"`
+	AccessId   string            `json:"accessId" yaml:"accessId"`
+	AccessKey  string            `json:"accessKey" yaml:"accessKey"`
+	Sign       string            `json:"sign" yaml:"sign"`
+	Template   string            `json:"template" yaml:"template"`
+	Region     string            `json:"region" yaml:"region"`
+	Endpoint   string            `json:"endpoint" yaml:"endpoint"`
+	Sender     string            `json:"sender" yaml:"sender"`
+	AppId      string            `json:"appId" yaml:"appId"`
+	ProjectId  string            `json:"projectId" yaml:"projectId"`
+	GoodsId    string            `json:"goodsId" yaml:"goodsId"`
+	BaseUrl    string            `json:"baseUrl" yaml:"baseUrl"`
+	SmsAccount string            `json:"smsAccount" yaml:"smsAccount"`
+	Extra      map[string]string `json:"extra" yaml:"extra"`
+
+	// Templates holds translations of Template by locale or region, see MessageTemplate.
+	Templates map[string]string `json:"templates" yaml:"templates"`
+	// MaxSegments and Transliterate set the SegmentPolicy of the providers sending plain text.
+	MaxSegments   int  `json:"maxSegments" yaml:"maxSegments"`
+	Transliterate bool `json:"transliterate" yaml:"transliterate"`
+
+	// HttpClient or Transport, if set, is used for the provider's HTTP requests.
+	HttpClient *http.Client      `json:"-" yaml:"-"`
+	Transport  http.RoundTripper `json:"-" yaml:"-"`
+}
+
+// NewSmsClientFromConfig validates config against the fields of its provider and creates the client.
+func NewSmsClientFromConfig(config *Config) (SmsClient, error) {
+	p, err := getProvider(config.Provider)
+	if err != nil {
+		return nil, err
+	}
+
+	err = config.validate(p.info)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := p.factory(config)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.Endpoint != "" {
+		setter, ok := client.(EndpointSetter)
+		if !ok {
+			return nil, fmt.Errorf("provider %s does not support a custom endpoint", config.Provider)
+		}
+		setter.SetEndpoint(config.Endpoint)
+	}
+
+	if len(config.Templates) > 0 {
+		setter, ok := client.(TemplateSetter)
+		if !ok {
+			return nil, fmt.Errorf("provider %s does not support localized templates", config.Provider)
+		}
+		setter.SetTemplate(&MessageTemplate{Text: config.Template, Locales: config.Templates})
+	}
+
+	if config.MaxSegments > 0 || config.Transliterate {
+		setter, ok := client.(SegmentPolicySetter)
+		if !ok {
+			return nil, fmt.Errorf("provider %s does not support a segment policy", config.Provider)
+		}
+		setter.SetSegmentPolicy(SegmentPolicy{MaxSegments: config.MaxSegments, Transliterate: config.Transliterate})
+	}
+
+	if httpClient := config.httpClient(); httpClient != nil {
+		setter, ok := client.(HttpClientSetter)
+		if !ok {
+			return nil, fmt.Errorf("provider %s does not support a custom http client", config.Provider)
+		}
+		setter.SetHttpClient(httpClient)
+	}
+
+	return client, nil
+}
+
+func (c *Config) httpClient() *http.Client {
+	if c.HttpClient != nil {
+		return c.HttpClient
+	}
+	if c.Transport != nil {
+		return &http.Client{Transport: c.Transport, Timeout: defaultHttpTimeout}
+	}
+	return nil
+}
+
+// Validate reports the required fields of the provider that config leaves empty.
+func (c *Config) Validate() error {
+	p, err := getProvider(c.Provider)
+	if err != nil {
+		return err
+	}
+
+	return c.validate(p.info)
+}
+
+func (c *Config) validate(info ProviderInfo) error {
+	missing := []string{}
+	for _, field := range info.Fields {
+		if field.Required && c.Get(field.Name) == "" {
+			missing = append(missing, field.Name)
+		}
+	}
+
+	if len(missing) > 0 {
+		return missingParameterError(strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+// Get returns the value of the field with the given ProviderField name.
+func (c *Config) Get(name string) string {
+	if p := c.fieldPtr(name); p != nil {
+		return *p
+	}
+	return c.Extra[name]
+}
+
+// Set sets the value of the field with the given ProviderField name.
+func (c *Config) Set(name string, value string) {
+	if p := c.fieldPtr(name); p != nil {
+		*p = value
+		return
+	}
+
+	if c.Extra == nil {
+		c.Extra = map[string]string{}
+	}
+	c.Extra[name] = value
+}
+
+func (c *Config) fieldPtr(name string) *string {
+	switch name {
+	case "provider":
+		return &c.Provider
+	case "accessId":
+		return &c.AccessId
+	case "accessKey":
+		return &c.AccessKey
+	case "sign":
+		return &c.Sign
+	case "template":
+		return &c.Template
+	case "region":
+		return &c.Region
+	case "endpoint":
+		return &c.Endpoint
+	case "sender":
+		return &c.Sender
+	case "appId":
+		return &c.AppId
+	case "projectId":
+		return &c.ProjectId
+	case "goodsId":
+		return &c.GoodsId
+	case "baseUrl":
+		return &c.BaseUrl
+	case "smsAccount":
+		return &c.SmsAccount
+	default:
+		return nil
+	}
+}
+
+// newConfig maps the positional NewSmsClient arguments onto a Config, using
+// the order of the provider's Other fields for the `other` arguments.
+func newConfig(info ProviderInfo, accessId string, accessKey string, sign string, template string, other []string) *Config {
+	config := &Config{
+		Provider:  info.Name,
+		AccessId:  accessId,
+		AccessKey: accessKey,
+		Sign:      sign,
+		Template:  template,
+	}
+
+	i := 0
+	for _, field := range info.Fields {
+		if !field.Other {
+			continue
+		}
+		if i >= len(other) {
+			break
+		}
+
+		config.Set(field.Name, other[i])
+		i++
+	}
+
+	return config
+}
@@ -0,0 +1,150 @@
+// Copyright 2024 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_sms_sender
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      Config
+		wantMissing string
+		wantErr     string
+	}{
+		{"complete", Config{Provider: AmazonSNS, AccessId: "id", AccessKey: "key", Template: "%s", Region: "us-east-1"}, "", ""},
+		{"every field missing", Config{Provider: AmazonSNS}, "accessId, accessKey, template, region", ""},
+		{"other fields missing", Config{Provider: AzureACS, AccessKey: "key", Template: "%s"}, "endpoint, sender", ""},
+		{"optional fields left out", Config{Provider: Aliyun, AccessId: "id", AccessKey: "key", Sign: "sign", Template: "SMS_1"}, "", ""},
+		{"extra field set", Config{Provider: testGateway, AccessId: "id", AccessKey: "key", Extra: map[string]string{"apiToken": "token"}}, "", ""},
+		{"extra field missing", Config{Provider: testGateway, AccessId: "id", AccessKey: "key", Extra: map[string]string{"channel": "otp"}}, "apiToken", ""},
+		{"unknown provider", Config{Provider: "Unknown SMS"}, "", "unsupported provider"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			switch {
+			case tt.wantMissing != "":
+				if !errors.Is(err, ErrMissingParameter) || !strings.HasSuffix(err.Error(), ": "+tt.wantMissing) {
+					t.Errorf("Validate() = %v, want ErrMissingParameter for %s", err, tt.wantMissing)
+				}
+			case tt.wantErr != "":
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Validate() = %v, want %q", err, tt.wantErr)
+				}
+			case err != nil:
+				t.Errorf("Validate() = %v, want nil", err)
+			}
+		})
+	}
+}
+
+func TestNewConfigOtherArguments(t *testing.T) {
+	tests := []struct {
+		provider string
+		other    []string
+		want     map[string]string
+	}{
+		{AmazonSNS, []string{"us-east-1"}, map[string]string{"region": "us-east-1"}},
+		{AzureACS, []string{"https://acs.example.com", "+18005550100"}, map[string]string{"endpoint": "https://acs.example.com", "sender": "+18005550100"}},
+		{HuaweiCloud, []string{"https://api.example.com", "csms12345678"}, map[string]string{"endpoint": "https://api.example.com", "sender": "csms12345678"}},
+		{TencentCloud, []string{"1400000000"}, map[string]string{"appId": "1400000000", "region": ""}},
+		{TencentCloud, []string{"1400000000", "ap-singapore", "ignored"}, map[string]string{"appId": "1400000000", "region": "ap-singapore"}},
+		{Infobip, []string{"https://xyz.api.infobip.com"}, map[string]string{"baseUrl": "https://xyz.api.infobip.com"}},
+		{SmsBao, []string{"123"}, map[string]string{"goodsId": "123"}},
+		{UCloud, []string{"org-1"}, map[string]string{"projectId": "org-1"}},
+		{VolcEngine, []string{"smsAccount"}, map[string]string{"smsAccount": "smsAccount"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.provider, func(t *testing.T) {
+			p, err := getProvider(tt.provider)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			config := newConfig(p.info, "id", "key", "sign", "template", tt.other)
+			if config.Provider != tt.provider || config.AccessId != "id" || config.AccessKey != "key" || config.Sign != "sign" || config.Template != "template" {
+				t.Errorf("config = %+v, want the positional arguments", config)
+			}
+			for name, want := range tt.want {
+				if got := config.Get(name); got != want {
+					t.Errorf("%s = %q, want %q", name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestNewSmsClientFromConfig(t *testing.T) {
+	twilio := Config{Provider: Twilio, AccessId: "AC123", AccessKey: "token", Template: "Your code is {{code}}", Sender: "+18005550100"}
+	withTemplates := twilio
+	withTemplates.Templates = map[string]string{"tr": "Kodunuz {{code}}"}
+	withoutTemplate := twilio
+	withoutTemplate.Template = ""
+
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr string
+	}{
+		{"twilio", twilio, ""},
+		{"localized templates", withTemplates, ""},
+		{"segment policy", Config{Provider: Twilio, AccessId: "AC123", AccessKey: "token", Template: "%s", Sender: "+18005550100", MaxSegments: 1}, ""},
+		{"missing field", withoutTemplate, "missing parameter: template"},
+		{"unknown provider", Config{Provider: "Unknown SMS"}, "unsupported provider: Unknown SMS"},
+		{"endpoint not supported", Config{Provider: MockSms, Endpoint: "https://sms.example.com"}, "does not support a custom endpoint"},
+		{"templates not supported", Config{Provider: MockSms, Templates: map[string]string{"tr": "Kodunuz %s"}}, "does not support localized templates"},
+		{"segment policy not supported", Config{Provider: MockSms, MaxSegments: 1}, "does not support a segment policy"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := NewSmsClientFromConfig(&tt.config)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, ok := client.(*TwilioClient); !ok {
+				t.Errorf("client = %T, want *TwilioClient", client)
+			}
+		})
+	}
+}
+
+func TestConfigGetSet(t *testing.T) {
+	config := &Config{}
+	config.Set("region", "cn-hangzhou")
+	config.Set("apiToken", "token")
+
+	if config.Region != "cn-hangzhou" || config.Get("region") != "cn-hangzhou" {
+		t.Errorf("region = %q, want it set on the Config field", config.Region)
+	}
+	if config.Extra["apiToken"] != "token" || config.Get("apiToken") != "token" {
+		t.Errorf("Extra = %v, want apiToken in it", config.Extra)
+	}
+	if got := config.Get("channel"); got != "" {
+		t.Errorf("Get(channel) = %q, want empty", got)
+	}
+}
@@ -0,0 +1,44 @@
+// Copyright 2024 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_sms_sender
+
+import "time"
+
+type DeliveryStatus string
+
+const (
+	// DeliveryStatusDelivered means the handset received the message.
+	DeliveryStatusDelivered DeliveryStatus = "delivered"
+	// DeliveryStatusFailed means the message will not be delivered.
+	DeliveryStatusFailed DeliveryStatus = "failed"
+	// DeliveryStatusPending means the message is queued or sent, but not delivered yet.
+	DeliveryStatusPending DeliveryStatus = "pending"
+	// DeliveryStatusUnknown means the provider status could not be mapped.
+	DeliveryStatusUnknown DeliveryStatus = "unknown"
+)
+
+// DeliveryReport is the delivery status of a message sent to one recipient.
+// MessageId matches RecipientResult.MessageId of the send, ErrorCode and
+// ErrorMessage are the provider's own, and Timestamp is the time of the status
+// as reported by the provider, or the time it was received.
+type DeliveryReport struct {
+	Provider     string
+	MessageId    string
+	Recipient    string
+	Status       DeliveryStatus
+	ErrorCode    string
+	ErrorMessage string
+	Timestamp    time.Time
+}
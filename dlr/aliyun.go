@@ -0,0 +1,62 @@
+// Copyright 2024 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dlr
+
+import (
+	"encoding/json"
+	"net/http"
+
+	go_sms_sender "github.com/casdoor/go-sms-sender"
+	"github.com/casdoor/go-sms-sender/internal/providerdata"
+	"github.com/casdoor/go-sms-sender/internal/webhook"
+)
+
+type aliyunReport struct {
+	PhoneNumber string `json:"phone_number"`
+	ReportTime  string `json:"report_time"`
+	Success     bool   `json:"success"`
+	ErrCode     string `json:"err_code"`
+	ErrMsg      string `json:"err_msg"`
+	BizId       string `json:"biz_id"`
+}
+
+// NewAliyunHandler handles the SmsReport messages Aliyun pushes over HTTP
+// batch push, whose URL must carry token.
+func NewAliyunHandler(token string, onReport ReportFunc) (*Handler, error) {
+	return newHandler(go_sms_sender.Aliyun, token, func(r *http.Request, body []byte) ([]go_sms_sender.DeliveryReport, []byte, error) {
+		var payload []aliyunReport
+		if err := json.Unmarshal(body, &payload); err != nil {
+			return nil, nil, err
+		}
+
+		reports := []go_sms_sender.DeliveryReport{}
+		for _, item := range payload {
+			report := go_sms_sender.DeliveryReport{
+				MessageId: item.BizId,
+				Recipient: item.PhoneNumber,
+				Status:    go_sms_sender.DeliveryStatusDelivered,
+				Timestamp: webhook.ParseTime("2006-01-02 15:04:05", item.ReportTime, providerdata.ChinaTime),
+			}
+			if !item.Success {
+				report.Status = go_sms_sender.DeliveryStatusFailed
+				report.ErrorCode = item.ErrCode
+				report.ErrorMessage = item.ErrMsg
+			}
+			reports = append(reports, report)
+		}
+
+		return reports, []byte(`{"code":0,"msg":"成功"}`), nil
+	}, onReport)
+}
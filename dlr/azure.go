@@ -0,0 +1,72 @@
+// Copyright 2024 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dlr
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	go_sms_sender "github.com/casdoor/go-sms-sender"
+	"github.com/casdoor/go-sms-sender/internal/webhook"
+)
+
+const azureDeliveryReportEvent = "Microsoft.Communication.SMSDeliveryReportReceived"
+
+type azureDeliveryReport struct {
+	MessageId             string `json:"messageId"`
+	To                    string `json:"to"`
+	DeliveryStatus        string `json:"deliveryStatus"`
+	DeliveryStatusDetails string `json:"deliveryStatusDetails"`
+	ReceivedTimestamp     string `json:"receivedTimestamp"`
+}
+
+// NewAzureHandler handles the SMSDeliveryReportReceived events of an Event
+// Grid webhook subscription, and answers its validation handshake. The
+// endpoint URL must carry token.
+func NewAzureHandler(token string, onReport ReportFunc) (*Handler, error) {
+	return newHandler(go_sms_sender.AzureACS, token, func(r *http.Request, body []byte) ([]go_sms_sender.DeliveryReport, []byte, error) {
+		events, ack, err := webhook.ParseEventGrid(body, azureDeliveryReportEvent)
+		if err != nil || ack != nil {
+			return nil, ack, err
+		}
+
+		reports := []go_sms_sender.DeliveryReport{}
+		for _, event := range events {
+			var data azureDeliveryReport
+			if err = json.Unmarshal(event, &data); err != nil {
+				return nil, nil, err
+			}
+
+			report := go_sms_sender.DeliveryReport{
+				MessageId: data.MessageId,
+				Recipient: data.To,
+				Status:    go_sms_sender.DeliveryStatusUnknown,
+				Timestamp: webhook.ParseTime(time.RFC3339Nano, data.ReceivedTimestamp, time.UTC),
+			}
+			switch data.DeliveryStatus {
+			case "Delivered":
+				report.Status = go_sms_sender.DeliveryStatusDelivered
+			case "Failed":
+				report.Status = go_sms_sender.DeliveryStatusFailed
+				report.ErrorCode = data.DeliveryStatus
+				report.ErrorMessage = data.DeliveryStatusDetails
+			}
+			reports = append(reports, report)
+		}
+
+		return reports, nil, nil
+	}, onReport)
+}
@@ -0,0 +1,97 @@
+// Copyright 2024 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package dlr receives the delivery reports providers post to webhooks.
+//
+// Every provider has a constructor returning an http.Handler, which verifies
+// the request, parses its payload into go_sms_sender.DeliveryReport values and
+// passes each of them to a ReportFunc. Providers signing their requests are
+// verified with the account credentials, the others with a token that must be
+// added to the webhook URL as the "token" query parameter. The constructors
+// return ErrMissingToken for an empty token or credential.
+package dlr
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	go_sms_sender "github.com/casdoor/go-sms-sender"
+	"github.com/casdoor/go-sms-sender/internal/webhook"
+)
+
+var (
+	ErrInvalidSignature = webhook.ErrInvalidSignature
+	ErrMissingToken     = webhook.ErrMissingToken
+)
+
+// ReportFunc handles a delivery report. An error makes the handler answer
+// with a server error, so that the provider retries the webhook.
+type ReportFunc func(ctx context.Context, report go_sms_sender.DeliveryReport) error
+
+// parseFunc verifies and parses a webhook request, and returns the body
+// acknowledging it.
+type parseFunc func(r *http.Request, body []byte) ([]go_sms_sender.DeliveryReport, []byte, error)
+
+// Handler is the webhook handler of a provider.
+type Handler struct {
+	handler *webhook.Handler
+}
+
+var _ http.Handler = &Handler{}
+
+// newHandler returns the handler of a provider verified by the token query
+// parameter, which must not be empty.
+func newHandler(provider string, token string, parse parseFunc, onReport ReportFunc) (*Handler, error) {
+	handler, err := webhook.New(token, handleReports(provider, parse, onReport))
+	if err != nil {
+		return nil, err
+	}
+	return &Handler{handler: handler}, nil
+}
+
+// newSignedHandler returns the handler of a provider signing its requests
+// with secret, which parse verifies.
+func newSignedHandler(provider string, secret string, parse parseFunc, onReport ReportFunc) (*Handler, error) {
+	handler, err := webhook.NewSigned(secret, handleReports(provider, parse, onReport))
+	if err != nil {
+		return nil, err
+	}
+	return &Handler{handler: handler}, nil
+}
+
+func handleReports(provider string, parse parseFunc, onReport ReportFunc) webhook.HandleFunc {
+	return func(r *http.Request, body []byte) ([]byte, error) {
+		reports, ack, err := parse(r, body)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, report := range reports {
+			report.Provider = provider
+			if report.Timestamp.IsZero() {
+				report.Timestamp = time.Now()
+			}
+
+			if err = onReport(r.Context(), report); err != nil {
+				return nil, webhook.CallbackError(err)
+			}
+		}
+		return ack, nil
+	}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.handler.ServeHTTP(w, r)
+}
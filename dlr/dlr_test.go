@@ -0,0 +1,103 @@
+// Copyright 2024 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dlr
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	go_sms_sender "github.com/casdoor/go-sms-sender"
+)
+
+// recorder collects the reports of a handler, and fails them with err.
+type recorder struct {
+	reports []go_sms_sender.DeliveryReport
+	err     error
+}
+
+func (r *recorder) onReport(ctx context.Context, report go_sms_sender.DeliveryReport) error {
+	r.reports = append(r.reports, report)
+	return r.err
+}
+
+// post serves a form POST to target and returns the response.
+func post(handler http.Handler, target string, body string, header http.Header) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	for key, values := range header {
+		r.Header[key] = values
+	}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
+
+func TestMissingToken(t *testing.T) {
+	var onReport ReportFunc = (&recorder{}).onReport
+	constructors := map[string]func() (*Handler, error){
+		"aliyun":  func() (*Handler, error) { return NewAliyunHandler("", onReport) },
+		"azure":   func() (*Handler, error) { return NewAzureHandler("", onReport) },
+		"huawei":  func() (*Handler, error) { return NewHuaweiHandler("", onReport) },
+		"infobip": func() (*Handler, error) { return NewInfobipHandler("", onReport) },
+		"msg91":   func() (*Handler, error) { return NewMsg91Handler("", onReport) },
+		"netgsm":  func() (*Handler, error) { return NewNetgsmHandler("", onReport) },
+		"submail": func() (*Handler, error) { return NewSubmailHandler("", onReport) },
+		"tencent": func() (*Handler, error) { return NewTencentHandler("", onReport) },
+		"twilio":  func() (*Handler, error) { return NewTwilioHandler("", "https://example.com/dlr", onReport) },
+	}
+
+	for name, constructor := range constructors {
+		if handler, err := constructor(); !errors.Is(err, ErrMissingToken) || handler != nil {
+			t.Errorf("%s: handler = %v, error = %v, want ErrMissingToken", name, handler, err)
+		}
+	}
+}
+
+func TestTokenCheck(t *testing.T) {
+	tests := []struct {
+		name        string
+		target      string
+		wantCode    int
+		wantReports int
+	}{
+		{"right token", "/dlr?token=secret", http.StatusOK, 1},
+		{"wrong token", "/dlr?token=secreT", http.StatusForbidden, 0},
+		{"token prefix", "/dlr?token=secre", http.StatusForbidden, 0},
+		{"no token", "/dlr", http.StatusForbidden, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := &recorder{}
+			handler, err := NewNetgsmHandler("secret", rec.onReport)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			w := post(handler, tt.target, "jobid=1&gsmno=905321234567&durum=1", nil)
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantCode)
+			}
+			if len(rec.reports) != tt.wantReports {
+				t.Errorf("got %d reports, want %d", len(rec.reports), tt.wantReports)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright 2024 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dlr
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+
+	go_sms_sender "github.com/casdoor/go-sms-sender"
+	"github.com/casdoor/go-sms-sender/internal/webhook"
+)
+
+// NewHuaweiHandler handles the reports Huawei Cloud posts to the
+// statusCallback URL of a send, see HuaweiClient.SetStatusCallback. The URL
+// must carry token.
+func NewHuaweiHandler(token string, onReport ReportFunc) (*Handler, error) {
+	return newHandler(go_sms_sender.HuaweiCloud, token, func(r *http.Request, body []byte) ([]go_sms_sender.DeliveryReport, []byte, error) {
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// DELIVRD is the only successful status, the others such as
+		// EXPIRED, UNDELIV or REJECTD are final failures.
+		status := go_sms_sender.DeliveryStatusFailed
+		switch form.Get("status") {
+		case "DELIVRD":
+			status = go_sms_sender.DeliveryStatusDelivered
+		case "ACCEPTD":
+			status = go_sms_sender.DeliveryStatusPending
+		case "":
+			status = go_sms_sender.DeliveryStatusUnknown
+		}
+
+		report := go_sms_sender.DeliveryReport{
+			MessageId: form.Get("smsMsgId"),
+			Recipient: form.Get("to"),
+			Status:    status,
+			Timestamp: webhook.ParseTime(time.RFC3339, form.Get("updateTime"), time.UTC),
+		}
+		if status == go_sms_sender.DeliveryStatusFailed {
+			report.ErrorCode = form.Get("status")
+		}
+
+		return []go_sms_sender.DeliveryReport{report}, nil, nil
+	}, onReport)
+}
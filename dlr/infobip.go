@@ -0,0 +1,87 @@
+// Copyright 2024 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dlr
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	go_sms_sender "github.com/casdoor/go-sms-sender"
+	"github.com/casdoor/go-sms-sender/internal/webhook"
+)
+
+const infobipTimeLayout = "2006-01-02T15:04:05.000-0700"
+
+type infobipReports struct {
+	Results []struct {
+		MessageId string `json:"messageId"`
+		To        string `json:"to"`
+		DoneAt    string `json:"doneAt"`
+		Status    struct {
+			GroupName   string `json:"groupName"`
+			Name        string `json:"name"`
+			Description string `json:"description"`
+		} `json:"status"`
+		Error struct {
+			Id          int    `json:"id"`
+			Name        string `json:"name"`
+			Description string `json:"description"`
+		} `json:"error"`
+	} `json:"results"`
+}
+
+var infobipStatuses = map[string]go_sms_sender.DeliveryStatus{
+	"PENDING":       go_sms_sender.DeliveryStatusPending,
+	"DELIVERED":     go_sms_sender.DeliveryStatusDelivered,
+	"UNDELIVERABLE": go_sms_sender.DeliveryStatusFailed,
+	"EXPIRED":       go_sms_sender.DeliveryStatusFailed,
+	"REJECTED":      go_sms_sender.DeliveryStatusFailed,
+}
+
+// NewInfobipHandler handles the delivery reports Infobip posts to the
+// notifyUrl of a message, which must carry token.
+func NewInfobipHandler(token string, onReport ReportFunc) (*Handler, error) {
+	return newHandler(go_sms_sender.Infobip, token, func(r *http.Request, body []byte) ([]go_sms_sender.DeliveryReport, []byte, error) {
+		var payload infobipReports
+		if err := json.Unmarshal(body, &payload); err != nil {
+			return nil, nil, err
+		}
+
+		reports := []go_sms_sender.DeliveryReport{}
+		for _, result := range payload.Results {
+			status, ok := infobipStatuses[result.Status.GroupName]
+			if !ok {
+				status = go_sms_sender.DeliveryStatusUnknown
+			}
+
+			report := go_sms_sender.DeliveryReport{
+				MessageId:    result.MessageId,
+				Recipient:    result.To,
+				Status:       status,
+				ErrorMessage: result.Status.Description,
+				Timestamp:    webhook.ParseTime(infobipTimeLayout, result.DoneAt, time.UTC),
+			}
+			if result.Error.Id != 0 {
+				report.ErrorCode = strconv.Itoa(result.Error.Id)
+				report.ErrorMessage = result.Error.Description
+			}
+			reports = append(reports, report)
+		}
+
+		return reports, nil, nil
+	}, onReport)
+}
@@ -0,0 +1,94 @@
+// Copyright 2024 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dlr
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"time"
+
+	go_sms_sender "github.com/casdoor/go-sms-sender"
+	"github.com/casdoor/go-sms-sender/internal/webhook"
+)
+
+type msg91Report struct {
+	RequestId string `json:"requestId"`
+	Report    []struct {
+		Date   string `json:"date"`
+		Number string `json:"number"`
+		Status string `json:"status"`
+		Desc   string `json:"desc"`
+	} `json:"report"`
+}
+
+var indiaTime = time.FixedZone("IST", 5*60*60+30*60)
+
+// NewMsg91Handler handles the delivery reports Msg91 posts to the webhook
+// URL, which must carry token.
+func NewMsg91Handler(token string, onReport ReportFunc) (*Handler, error) {
+	return newHandler(go_sms_sender.Msg91, token, func(r *http.Request, body []byte) ([]go_sms_sender.DeliveryReport, []byte, error) {
+		// The reports are posted as JSON, or as JSON in the "data" form field.
+		data := bytes.TrimSpace(body)
+		if len(data) > 0 && data[0] != '[' {
+			form, err := url.ParseQuery(string(body))
+			if err != nil {
+				return nil, nil, err
+			}
+			data = []byte(form.Get("data"))
+		}
+
+		var payload []msg91Report
+		if err := json.Unmarshal(data, &payload); err != nil {
+			return nil, nil, err
+		}
+
+		reports := []go_sms_sender.DeliveryReport{}
+		for _, request := range payload {
+			for _, item := range request.Report {
+				report := go_sms_sender.DeliveryReport{
+					MessageId: request.RequestId,
+					Recipient: item.Number,
+					Status:    msg91Status(item.Status),
+					Timestamp: webhook.ParseTime("2006-01-02 15:04:05", item.Date, indiaTime),
+				}
+				if report.Status == go_sms_sender.DeliveryStatusFailed {
+					report.ErrorCode = item.Status
+					report.ErrorMessage = item.Desc
+				}
+				reports = append(reports, report)
+			}
+		}
+
+		return reports, nil, nil
+	}, onReport)
+}
+
+// msg91Status maps the Msg91 report codes: 1 is delivered, 3, 5 and 8 are
+// pending, and the others, such as 2 failed, 9 NDNC, 16 rejected and 17
+// blocked, are failures.
+func msg91Status(code string) go_sms_sender.DeliveryStatus {
+	switch code {
+	case "1":
+		return go_sms_sender.DeliveryStatusDelivered
+	case "3", "5", "8":
+		return go_sms_sender.DeliveryStatusPending
+	case "":
+		return go_sms_sender.DeliveryStatusUnknown
+	default:
+		return go_sms_sender.DeliveryStatusFailed
+	}
+}
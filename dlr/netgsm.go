@@ -0,0 +1,59 @@
+// Copyright 2024 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dlr
+
+import (
+	"net/http"
+	"net/url"
+
+	go_sms_sender "github.com/casdoor/go-sms-sender"
+	"github.com/casdoor/go-sms-sender/internal/providerdata"
+	"github.com/casdoor/go-sms-sender/internal/webhook"
+)
+
+// NewNetgsmHandler handles the reports Netgsm sends to the report URL of the
+// account, as query or form parameters jobid, gsmno, durum and tarih. The URL
+// must carry token.
+func NewNetgsmHandler(token string, onReport ReportFunc) (*Handler, error) {
+	return newHandler(go_sms_sender.Netgsm, token, func(r *http.Request, body []byte) ([]go_sms_sender.DeliveryReport, []byte, error) {
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			return nil, nil, err
+		}
+		for key, values := range r.URL.Query() {
+			if _, ok := form[key]; !ok {
+				form[key] = values
+			}
+		}
+
+		code := form.Get("durum")
+		status, ok := providerdata.NetgsmStatuses[code]
+		if !ok {
+			status = string(go_sms_sender.DeliveryStatusUnknown)
+		}
+
+		report := go_sms_sender.DeliveryReport{
+			MessageId: form.Get("jobid"),
+			Recipient: form.Get("gsmno"),
+			Status:    go_sms_sender.DeliveryStatus(status),
+			Timestamp: webhook.ParseTime(providerdata.NetgsmTimeLayout, form.Get("tarih"), providerdata.TurkeyTime),
+		}
+		if report.Status == go_sms_sender.DeliveryStatusFailed {
+			report.ErrorCode = code
+		}
+
+		return []go_sms_sender.DeliveryReport{report}, []byte("OK"), nil
+	}, onReport)
+}
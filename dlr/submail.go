@@ -0,0 +1,71 @@
+// Copyright 2024 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dlr
+
+import (
+	"crypto/md5"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	go_sms_sender "github.com/casdoor/go-sms-sender"
+)
+
+// NewSubmailHandler handles the SUBHOOK events of Submail, verifying their
+// signature, the MD5 of the token posted with the event and the app key.
+// Events other than request, sending, delivered and dropped are ignored.
+func NewSubmailHandler(appKey string, onReport ReportFunc) (*Handler, error) {
+	return newSignedHandler(go_sms_sender.SUBMAIL, appKey, func(r *http.Request, body []byte) ([]go_sms_sender.DeliveryReport, []byte, error) {
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		sum := md5.Sum([]byte(form.Get("token") + appKey))
+		if subtle.ConstantTimeCompare([]byte(form.Get("signature")), []byte(hex.EncodeToString(sum[:]))) != 1 {
+			return nil, nil, ErrInvalidSignature
+		}
+
+		var status go_sms_sender.DeliveryStatus
+		switch form.Get("events") {
+		case "request", "sending":
+			status = go_sms_sender.DeliveryStatusPending
+		case "delivered":
+			status = go_sms_sender.DeliveryStatusDelivered
+		case "dropped":
+			status = go_sms_sender.DeliveryStatusFailed
+		default:
+			return nil, nil, nil
+		}
+
+		report := go_sms_sender.DeliveryReport{
+			MessageId: form.Get("send_id"),
+			Recipient: form.Get("address"),
+			Status:    status,
+		}
+		if timestamp, err := strconv.ParseInt(form.Get("timestamp"), 10, 64); err == nil {
+			report.Timestamp = time.Unix(timestamp, 0)
+		}
+		if status == go_sms_sender.DeliveryStatusFailed {
+			report.ErrorCode = form.Get("report")
+			report.ErrorMessage = form.Get("report_desc")
+		}
+
+		return []go_sms_sender.DeliveryReport{report}, nil, nil
+	}, onReport)
+}
@@ -0,0 +1,92 @@
+// Copyright 2024 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dlr
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	go_sms_sender "github.com/casdoor/go-sms-sender"
+)
+
+func signSubmail(token string, appKey string) string {
+	sum := md5.Sum([]byte(token + appKey))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestSubmailHandler(t *testing.T) {
+	const appKey = "app-key"
+	event := func(events string, signature string) url.Values {
+		return url.Values{
+			"events":      {events},
+			"send_id":     {"093c0a7df143c087d6cba9cdf0cf3738"},
+			"address":     {"13800138000"},
+			"timestamp":   {"1700000000"},
+			"token":       {"2f2c1df7f1b0bb6d0d5b7b4a"},
+			"signature":   {signature},
+			"report":      {"UNDELIV"},
+			"report_desc": {"recipient unreachable"},
+		}
+	}
+	signature := signSubmail("2f2c1df7f1b0bb6d0d5b7b4a", appKey)
+	report := func(status go_sms_sender.DeliveryStatus, errorCode string, errorMessage string) *go_sms_sender.DeliveryReport {
+		return &go_sms_sender.DeliveryReport{
+			Provider:     go_sms_sender.SUBMAIL,
+			MessageId:    "093c0a7df143c087d6cba9cdf0cf3738",
+			Recipient:    "13800138000",
+			Status:       status,
+			ErrorCode:    errorCode,
+			ErrorMessage: errorMessage,
+			Timestamp:    time.Unix(1700000000, 0),
+		}
+	}
+	tests := []struct {
+		name       string
+		form       url.Values
+		wantCode   int
+		wantReport *go_sms_sender.DeliveryReport
+	}{
+		{"delivered", event("delivered", signature), http.StatusOK, report(go_sms_sender.DeliveryStatusDelivered, "", "")},
+		{"sending", event("sending", signature), http.StatusOK, report(go_sms_sender.DeliveryStatusPending, "", "")},
+		{"dropped", event("dropped", signature), http.StatusOK, report(go_sms_sender.DeliveryStatusFailed, "UNDELIV", "recipient unreachable")},
+		{"other event", event("unsubscribe", signature), http.StatusOK, nil},
+		{"other app key", event("delivered", signSubmail("2f2c1df7f1b0bb6d0d5b7b4a", "other-key")), http.StatusForbidden, nil},
+		{"other token", event("delivered", signSubmail("other-token", appKey)), http.StatusForbidden, nil},
+		{"no signature", event("delivered", ""), http.StatusForbidden, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := &recorder{}
+			handler, err := NewSubmailHandler(appKey, rec.onReport)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			w := post(handler, "/dlr/submail", tt.form.Encode(), nil)
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantCode, w.Body.String())
+			}
+			checkReport(t, rec.reports, tt.wantReport)
+			if tt.wantReport != nil && !rec.reports[0].Timestamp.Equal(tt.wantReport.Timestamp) {
+				t.Errorf("timestamp = %s, want %s", rec.reports[0].Timestamp, tt.wantReport.Timestamp)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+// Copyright 2024 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dlr
+
+import (
+	"encoding/json"
+	"net/http"
+
+	go_sms_sender "github.com/casdoor/go-sms-sender"
+	"github.com/casdoor/go-sms-sender/internal/providerdata"
+	"github.com/casdoor/go-sms-sender/internal/webhook"
+)
+
+type tencentReport struct {
+	UserReceiveTime string `json:"user_receive_time"`
+	NationCode      string `json:"nationcode"`
+	Mobile          string `json:"mobile"`
+	ReportStatus    string `json:"report_status"`
+	ErrMsg          string `json:"errmsg"`
+	Description     string `json:"description"`
+	Sid             string `json:"sid"`
+}
+
+// NewTencentHandler handles the delivery status callbacks of Tencent Cloud
+// SMS, whose URL must carry token.
+func NewTencentHandler(token string, onReport ReportFunc) (*Handler, error) {
+	return newHandler(go_sms_sender.TencentCloud, token, func(r *http.Request, body []byte) ([]go_sms_sender.DeliveryReport, []byte, error) {
+		var payload []tencentReport
+		if err := json.Unmarshal(body, &payload); err != nil {
+			return nil, nil, err
+		}
+
+		reports := []go_sms_sender.DeliveryReport{}
+		for _, item := range payload {
+			report := go_sms_sender.DeliveryReport{
+				MessageId: item.Sid,
+				Recipient: "+" + item.NationCode + item.Mobile,
+				Status:    go_sms_sender.DeliveryStatusDelivered,
+				Timestamp: webhook.ParseTime("2006-01-02 15:04:05", item.UserReceiveTime, providerdata.ChinaTime),
+			}
+			if item.ReportStatus != "SUCCESS" {
+				report.Status = go_sms_sender.DeliveryStatusFailed
+				report.ErrorCode = item.ErrMsg
+				report.ErrorMessage = item.Description
+			}
+			reports = append(reports, report)
+		}
+
+		return reports, []byte(`{"result":0,"errmsg":"OK"}`), nil
+	}, onReport)
+}
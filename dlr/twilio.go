@@ -0,0 +1,49 @@
+// Copyright 2024 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dlr
+
+import (
+	"net/http"
+
+	go_sms_sender "github.com/casdoor/go-sms-sender"
+	"github.com/casdoor/go-sms-sender/internal/providerdata"
+	"github.com/casdoor/go-sms-sender/internal/webhook"
+)
+
+// NewTwilioHandler handles the StatusCallback of Twilio messages, verifying
+// the X-Twilio-Signature header with the auth token. webhookURL is the
+// callback URL as configured in Twilio, it may be empty if the handler sees
+// the same URL, i.e. no proxy rewrites the scheme, host or path.
+func NewTwilioHandler(authToken string, webhookURL string, onReport ReportFunc) (*Handler, error) {
+	return newSignedHandler(go_sms_sender.Twilio, authToken, func(r *http.Request, body []byte) ([]go_sms_sender.DeliveryReport, []byte, error) {
+		form, err := webhook.ParseTwilioForm(r, body, authToken, webhookURL)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		status, ok := providerdata.TwilioStatuses[form.Get("MessageStatus")]
+		if !ok {
+			status = string(go_sms_sender.DeliveryStatusUnknown)
+		}
+
+		return []go_sms_sender.DeliveryReport{{
+			MessageId:    form.Get("MessageSid"),
+			Recipient:    form.Get("To"),
+			Status:       go_sms_sender.DeliveryStatus(status),
+			ErrorCode:    form.Get("ErrorCode"),
+			ErrorMessage: form.Get("ErrorMessage"),
+		}}, nil, nil
+	}, onReport)
+}
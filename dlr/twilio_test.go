@@ -0,0 +1,177 @@
+// Copyright 2024 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dlr
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/url"
+	"sort"
+	"testing"
+
+	go_sms_sender "github.com/casdoor/go-sms-sender"
+)
+
+// signTwilio computes the X-Twilio-Signature of form posted to callbackURL,
+// as documented by Twilio.
+func signTwilio(authToken string, callbackURL string, form url.Values) string {
+	data := callbackURL
+	keys := []string{}
+	for key := range form {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		data += key + form.Get(key)
+	}
+
+	mac := hmac.New(sha1.New, []byte(authToken))
+	mac.Write([]byte(data))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestTwilioHandler(t *testing.T) {
+	const (
+		authToken  = "auth-token"
+		webhookURL = "https://example.com/dlr/twilio?account=1"
+	)
+	delivered := url.Values{"MessageSid": {"SM123"}, "To": {"+12065550100"}, "MessageStatus": {"delivered"}}
+	undelivered := url.Values{"MessageSid": {"SM124"}, "To": {"+12065550101"}, "MessageStatus": {"undelivered"}, "ErrorCode": {"30003"}}
+	tests := []struct {
+		name       string
+		webhookURL string
+		target     string
+		header     http.Header
+		form       url.Values
+		signature  string
+		callback   error
+		wantCode   int
+		wantReport *go_sms_sender.DeliveryReport
+	}{
+		{
+			name:       "delivered",
+			webhookURL: webhookURL,
+			form:       delivered,
+			signature:  signTwilio(authToken, webhookURL, delivered),
+			wantCode:   http.StatusOK,
+			wantReport: &go_sms_sender.DeliveryReport{Provider: go_sms_sender.Twilio, MessageId: "SM123", Recipient: "+12065550100", Status: go_sms_sender.DeliveryStatusDelivered},
+		},
+		{
+			name:       "undelivered",
+			webhookURL: webhookURL,
+			form:       undelivered,
+			signature:  signTwilio(authToken, webhookURL, undelivered),
+			wantCode:   http.StatusOK,
+			wantReport: &go_sms_sender.DeliveryReport{Provider: go_sms_sender.Twilio, MessageId: "SM124", Recipient: "+12065550101", Status: go_sms_sender.DeliveryStatusFailed, ErrorCode: "30003"},
+		},
+		{
+			name:       "request URL behind a TLS proxy",
+			target:     "/dlr/twilio?account=1",
+			header:     http.Header{"X-Forwarded-Proto": {"https"}},
+			form:       delivered,
+			signature:  signTwilio(authToken, "https://example.com/dlr/twilio?account=1", delivered),
+			wantCode:   http.StatusOK,
+			wantReport: &go_sms_sender.DeliveryReport{Provider: go_sms_sender.Twilio, MessageId: "SM123", Recipient: "+12065550100", Status: go_sms_sender.DeliveryStatusDelivered},
+		},
+		{
+			name:       "other auth token",
+			webhookURL: webhookURL,
+			form:       delivered,
+			signature:  signTwilio("other-token", webhookURL, delivered),
+			wantCode:   http.StatusForbidden,
+		},
+		{
+			name:       "other URL",
+			webhookURL: webhookURL,
+			form:       delivered,
+			signature:  signTwilio(authToken, "https://example.com/dlr/twilio", delivered),
+			wantCode:   http.StatusForbidden,
+		},
+		{
+			name:       "tampered form",
+			webhookURL: webhookURL,
+			form:       url.Values{"MessageSid": {"SM123"}, "To": {"+12065550100"}, "MessageStatus": {"failed"}},
+			signature:  signTwilio(authToken, webhookURL, delivered),
+			wantCode:   http.StatusForbidden,
+		},
+		{
+			name:       "no signature",
+			webhookURL: webhookURL,
+			form:       delivered,
+			wantCode:   http.StatusForbidden,
+		},
+		{
+			name:       "callback error",
+			webhookURL: webhookURL,
+			form:       delivered,
+			signature:  signTwilio(authToken, webhookURL, delivered),
+			callback:   errors.New("database down"),
+			wantCode:   http.StatusInternalServerError,
+			wantReport: &go_sms_sender.DeliveryReport{Provider: go_sms_sender.Twilio, MessageId: "SM123", Recipient: "+12065550100", Status: go_sms_sender.DeliveryStatusDelivered},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := &recorder{err: tt.callback}
+			handler, err := NewTwilioHandler(authToken, tt.webhookURL, rec.onReport)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			target := tt.target
+			if target == "" {
+				target = "/dlr/twilio"
+			}
+			header := http.Header{"X-Twilio-Signature": {tt.signature}}
+			for key, values := range tt.header {
+				header[key] = values
+			}
+
+			w := post(handler, "http://example.com"+target, tt.form.Encode(), header)
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantCode, w.Body.String())
+			}
+			checkReport(t, rec.reports, tt.wantReport)
+		})
+	}
+}
+
+// checkReport compares the only report received, ignoring its timestamp,
+// with want, or checks that none was received if want is nil.
+func checkReport(t *testing.T, reports []go_sms_sender.DeliveryReport, want *go_sms_sender.DeliveryReport) {
+	t.Helper()
+	if want == nil {
+		if len(reports) != 0 {
+			t.Errorf("got reports %+v, want none", reports)
+		}
+		return
+	}
+	if len(reports) != 1 {
+		t.Fatalf("got reports %+v, want one", reports)
+	}
+
+	got := reports[0]
+	if got.Timestamp.IsZero() {
+		t.Error("report has no timestamp")
+	}
+	got.Timestamp = want.Timestamp
+	if got != *want {
+		t.Errorf("report = %+v, want %+v", got, *want)
+	}
+}
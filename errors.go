@@ -0,0 +1,97 @@
+// Copyright 2024 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_sms_sender
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// The sentinel errors below classify provider failures. Use errors.Is to test
+// an error returned by SendMessage against them.
+var (
+	ErrInvalidCredentials  = errors.New("invalid credentials")
+	ErrInvalidNumber       = errors.New("invalid phone number")
+	ErrRateLimited         = errors.New("rate limited")
+	ErrContentRejected     = errors.New("content rejected")
+	ErrInsufficientBalance = errors.New("insufficient balance")
+	ErrProviderUnavailable = errors.New("provider unavailable")
+	ErrMissingParameter    = errors.New("missing parameter")
+)
+
+// SmsError is a failure reported by a provider, keeping the raw provider code.
+// Kind is one of the sentinel errors, or nil if the code is not classified.
+// RetryAfter is the delay asked by the provider before retrying, if any.
+type SmsError struct {
+	Provider   string
+	Code       string
+	Message    string
+	Kind       error
+	RetryAfter time.Duration
+}
+
+func (e *SmsError) Error() string {
+	msg := e.Message
+	if msg == "" && e.Kind != nil {
+		msg = e.Kind.Error()
+	}
+	if e.Code != "" {
+		msg = fmt.Sprintf("%s (code: %s)", msg, e.Code)
+	}
+	if e.Provider != "" {
+		msg = fmt.Sprintf("%s: %s", e.Provider, msg)
+	}
+	return msg
+}
+
+func (e *SmsError) Unwrap() error {
+	return e.Kind
+}
+
+// newSmsError classifies code with codes, the provider's table of known codes.
+func newSmsError(provider string, code string, message string, codes map[string]error) *SmsError {
+	return &SmsError{
+		Provider: provider,
+		Code:     code,
+		Message:  message,
+		Kind:     codes[code],
+	}
+}
+
+// newSmsErrorByPrefix classifies code with the longest matching prefix in codes,
+// for providers whose codes are dotted families such as "LimitExceeded.PhoneNumberDailyLimit".
+func newSmsErrorByPrefix(provider string, code string, message string, codes map[string]error) *SmsError {
+	var kind error
+	matched := -1
+	for prefix, err := range codes {
+		if strings.HasPrefix(code, prefix) && len(prefix) > matched {
+			kind = err
+			matched = len(prefix)
+		}
+	}
+
+	return &SmsError{
+		Provider: provider,
+		Code:     code,
+		Message:  message,
+		Kind:     kind,
+	}
+}
+
+func missingParameterError(name string) error {
+	return fmt.Errorf("%w: %s", ErrMissingParameter, name)
+}
@@ -0,0 +1,139 @@
+// Copyright 2024 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_sms_sender
+
+import (
+	"context"
+	"errors"
+)
+
+// ParamTranslator maps the template parameters of a send to the ones expected
+// by the template of a provider.
+type ParamTranslator func(param map[string]string) map[string]string
+
+// FailoverProvider is one client of a FailoverClient. Translate is optional,
+// Name defaults to the provider reported in the client's SendResult.
+type FailoverProvider struct {
+	Name      string
+	Client    SmsClient
+	Translate ParamTranslator
+}
+
+// FailoverClient sends through its providers in order, moving on to the next
+// one when a send fails with an error that is not permanent, see IsPermanent.
+// Recipients accepted by a provider are not sent again, and the provider that
+// delivered each recipient is reported in RecipientResult.Provider. Providers
+// whose client is not available, see AvailabilityReporter, are skipped.
+type FailoverClient struct {
+	providers []FailoverProvider
+}
+
+var _ ResultSmsClient = &FailoverClient{}
+
+func NewFailoverClient(providers ...FailoverProvider) *FailoverClient {
+	return &FailoverClient{
+		providers: providers,
+	}
+}
+
+// RenameParams returns a ParamTranslator renaming the parameters listed in
+// names, e.g. {"code": "0"} for a Tencent Cloud template. Other parameters are kept.
+func RenameParams(names map[string]string) ParamTranslator {
+	return func(param map[string]string) map[string]string {
+		translated := make(map[string]string, len(param))
+		for key, value := range param {
+			if name, ok := names[key]; ok {
+				key = name
+			}
+			translated[key] = value
+		}
+		return translated
+	}
+}
+
+func (c *FailoverClient) SendMessage(param map[string]string, targetPhoneNumber ...string) error {
+	return c.SendMessageContext(context.Background(), param, targetPhoneNumber...)
+}
+
+func (c *FailoverClient) SendMessageContext(ctx context.Context, param map[string]string, targetPhoneNumber ...string) error {
+	_, err := c.SendMessageResult(ctx, param, targetPhoneNumber...)
+	return err
+}
+
+func (c *FailoverClient) SendMessageResult(ctx context.Context, param map[string]string, targetPhoneNumber ...string) (*SendResult, error) {
+	if len(c.providers) == 0 {
+		return nil, missingParameterError("providers")
+	}
+
+	result := &SendResult{Recipients: []RecipientResult{}}
+	remaining := targetPhoneNumber
+	var failed []RecipientResult
+	var err error
+	for _, provider := range c.providers {
+		if reporter, ok := provider.Client.(AvailabilityReporter); ok && !reporter.Available() {
+			if err == nil {
+				err = ErrCircuitOpen
+			}
+			continue
+		}
+
+		providerParam := param
+		if provider.Translate != nil {
+			providerParam = provider.Translate(param)
+		}
+
+		var providerResult *SendResult
+		providerResult, err = SendMessageWithResult(ctx, provider.Client, providerParam, remaining...)
+
+		failed = nil
+		if providerResult != nil {
+			name := provider.Name
+			if name == "" {
+				name = providerResult.Provider
+			}
+			result.Provider = name
+			result.RequestId = providerResult.RequestId
+
+			for _, recipient := range providerResult.Recipients {
+				if recipient.Provider == "" {
+					recipient.Provider = name
+				}
+
+				if err != nil && recipient.Status != SendStatusAccepted {
+					failed = append(failed, recipient)
+					continue
+				}
+
+				result.Recipients = append(result.Recipients, recipient)
+				remaining = withoutRecipient(remaining, recipient.PhoneNumber)
+			}
+		}
+
+		if err == nil || len(remaining) == 0 || IsPermanent(err) {
+			break
+		}
+	}
+
+	result.Recipients = append(result.Recipients, failed...)
+	return result, err
+}
+
+// IsPermanent reports whether err is caused by the message itself, so that
+// another provider would fail the same way: an invalid number, rejected
+// content, an opted-out recipient, or a cancelled or expired context.
+func IsPermanent(err error) bool {
+	return errors.Is(err, ErrInvalidNumber) || errors.Is(err, ErrContentRejected) || errors.Is(err, ErrOptedOut) ||
+		errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
@@ -0,0 +1,173 @@
+// Copyright 2024 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_sms_sender
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// unavailableClient is a client whose circuit is open.
+type unavailableClient struct {
+	fakeClient
+}
+
+func (c *unavailableClient) Available() bool {
+	return false
+}
+
+// recipientSummary lists the recipients of result as "number provider status".
+func recipientSummary(result *SendResult) []string {
+	summary := []string{}
+	if result == nil {
+		return summary
+	}
+	for _, recipient := range result.Recipients {
+		summary = append(summary, recipient.PhoneNumber+" "+recipient.Provider+" "+string(recipient.Status))
+	}
+	return summary
+}
+
+func TestFailoverClientPartialSuccess(t *testing.T) {
+	a, b := "+8613800138000", "+8613900139000"
+	tests := []struct {
+		name           string
+		primary        []fakeReply
+		secondary      []fakeReply
+		primaryDown    bool
+		wantPrimary    [][]string
+		wantSecondary  [][]string
+		wantRecipients []string
+		wantErr        error
+	}{
+		{
+			name:           "primary succeeds",
+			wantPrimary:    [][]string{{a, b}},
+			wantRecipients: []string{a + " primary accepted", b + " primary accepted"},
+		},
+		{
+			name:           "rejected recipient moves on",
+			primary:        []fakeReply{{rejected: []string{b}, err: errUnavailable}},
+			wantPrimary:    [][]string{{a, b}},
+			wantSecondary:  [][]string{{b}},
+			wantRecipients: []string{a + " primary accepted", b + " secondary accepted"},
+		},
+		{
+			name:           "recipients reported in national format",
+			primary:        []fakeReply{{rejected: []string{b}, format: chineseNational, err: errUnavailable}},
+			wantPrimary:    [][]string{{a, b}},
+			wantSecondary:  [][]string{{b}},
+			wantRecipients: []string{"13800138000 primary accepted", b + " secondary accepted"},
+		},
+		{
+			name:           "send without result moves on with all",
+			primary:        []fakeReply{{noResult: true, err: errUnavailable}},
+			wantPrimary:    [][]string{{a, b}},
+			wantSecondary:  [][]string{{a, b}},
+			wantRecipients: []string{a + " secondary accepted", b + " secondary accepted"},
+		},
+		{
+			name:           "permanent error stops",
+			primary:        []fakeReply{{rejected: []string{b}, err: errBadNumber}},
+			wantPrimary:    [][]string{{a, b}},
+			wantRecipients: []string{a + " primary accepted", b + " primary rejected"},
+			wantErr:        ErrInvalidNumber,
+		},
+		{
+			name:           "every provider fails",
+			primary:        []fakeReply{{rejected: []string{b}, err: errUnavailable}},
+			secondary:      []fakeReply{{rejected: []string{b}, err: errUnavailable}},
+			wantPrimary:    [][]string{{a, b}},
+			wantSecondary:  [][]string{{b}},
+			wantRecipients: []string{a + " primary accepted", b + " secondary rejected"},
+			wantErr:        ErrProviderUnavailable,
+		},
+		{
+			name:           "open circuit is skipped",
+			primaryDown:    true,
+			wantSecondary:  [][]string{{a, b}},
+			wantRecipients: []string{a + " secondary accepted", b + " secondary accepted"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			primary := &unavailableClient{fakeClient{replies: tt.primary}}
+			var primaryClient SmsClient = &primary.fakeClient
+			if tt.primaryDown {
+				primaryClient = primary
+			}
+			secondary := &fakeClient{replies: tt.secondary}
+			failover := NewFailoverClient(
+				FailoverProvider{Name: "primary", Client: primaryClient},
+				FailoverProvider{Name: "secondary", Client: secondary},
+			)
+
+			result, err := failover.SendMessageResult(context.Background(), nil, a, b)
+			if !errors.Is(err, tt.wantErr) || (err != nil) != (tt.wantErr != nil) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(primary.calls, tt.wantPrimary) {
+				t.Errorf("primary calls = %v, want %v", primary.calls, tt.wantPrimary)
+			}
+			if !reflect.DeepEqual(secondary.calls, tt.wantSecondary) {
+				t.Errorf("secondary calls = %v, want %v", secondary.calls, tt.wantSecondary)
+			}
+			if got := recipientSummary(result); !reflect.DeepEqual(got, tt.wantRecipients) {
+				t.Errorf("recipients = %v, want %v", got, tt.wantRecipients)
+			}
+		})
+	}
+}
+
+func TestFailoverClientOverRetryClient(t *testing.T) {
+	a, b := "+8613800138000", "+8613900139000"
+	primary := &fakeClient{replies: []fakeReply{
+		{rejected: []string{b}, err: errUnavailable},
+		{noResult: true, err: errUnavailable},
+	}}
+	secondary := &fakeClient{}
+	failover := NewFailoverClient(
+		FailoverProvider{Name: "primary", Client: NewRetryClient(primary, RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond})},
+		FailoverProvider{Name: "secondary", Client: secondary},
+	)
+
+	result, err := failover.SendMessageResult(context.Background(), nil, a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := [][]string{{a, b}, {b}}; !reflect.DeepEqual(primary.calls, want) {
+		t.Errorf("primary calls = %v, want %v", primary.calls, want)
+	}
+	if want := [][]string{{b}}; !reflect.DeepEqual(secondary.calls, want) {
+		t.Errorf("secondary calls = %v, want %v", secondary.calls, want)
+	}
+	want := []string{a + " primary accepted", b + " secondary accepted"}
+	if got := recipientSummary(result); !reflect.DeepEqual(got, want) {
+		t.Errorf("recipients = %v, want %v", got, want)
+	}
+}
+
+func TestRenameParams(t *testing.T) {
+	translate := RenameParams(map[string]string{"code": "0"})
+	got := translate(map[string]string{"code": "123456", "name": "casdoor"})
+	want := map[string]string{"0": "123456", "name": "casdoor"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("RenameParams() = %v, want %v", got, want)
+	}
+}
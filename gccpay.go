@@ -16,6 +16,7 @@ package go_sms_sender
 
 import (
 	"bytes"
+	"context"
 	"crypto/md5"
 	"crypto/rand"
 	"encoding/base64"
@@ -27,10 +28,14 @@ import (
 	"time"
 )
 
+const gccpayEndpoint = "https://smscenter.sgate.sa"
+
 type GCCPAYClient struct {
+	endpoint   string
 	clientname string
 	secret     string
 	template   string
+	httpClient *http.Client
 }
 
 type params struct {
@@ -39,16 +44,43 @@ type params struct {
 	TemplateParams map[string]string `json:"template_params"`
 }
 
+// GCCPAYResult is the sendSms response, code 0 or 200 means success.
+type GCCPAYResult struct {
+	Code json.Number `json:"code"`
+	Msg  string      `json:"msg"`
+}
+
+func init() {
+	Register(GCCPAY, func(config *Config) (SmsClient, error) {
+		return GetGCCPAYClient(config.AccessId, config.AccessKey, config.Template)
+	},
+		requiredField("accessId", "Client Name"),
+		requiredField("accessKey", "Secret"),
+		requiredField("template", "Template Code"),
+		optionalField("endpoint", "Endpoint"),
+	)
+}
+
 func GetGCCPAYClient(clientname string, secret string, template string) (*GCCPAYClient, error) {
 	gccPayClient := &GCCPAYClient{
+		endpoint:   gccpayEndpoint,
 		clientname: clientname,
 		secret:     secret,
 		template:   template,
+		httpClient: newDefaultHttpClient(),
 	}
 
 	return gccPayClient, nil
 }
 
+func (c *GCCPAYClient) SetHttpClient(httpClient *http.Client) {
+	c.httpClient = httpClient
+}
+
+func (c *GCCPAYClient) SetEndpoint(endpoint string) {
+	c.endpoint = strings.TrimSuffix(endpoint, "/")
+}
+
 func RandStringBytesCrypto(n int) (string, error) {
 	b := make([]byte, n)
 	_, err := rand.Read(b)
@@ -65,24 +97,34 @@ func Md5(str string) string {
 }
 
 func (c *GCCPAYClient) SendMessage(param map[string]string, targetPhoneNumber ...string) error {
+	return c.SendMessageContext(context.Background(), param, targetPhoneNumber...)
+}
+
+func (c *GCCPAYClient) SendMessageContext(ctx context.Context, param map[string]string, targetPhoneNumber ...string) error {
+	_, err := c.SendMessageResult(ctx, param, targetPhoneNumber...)
+	return err
+}
+
+func (c *GCCPAYClient) SendMessageResult(ctx context.Context, param map[string]string, targetPhoneNumber ...string) (*SendResult, error) {
 	_, ok := param["code"]
 	if !ok {
-		return fmt.Errorf("missing parameter: code")
+		return nil, missingParameterError("code")
 	}
 
 	if len(targetPhoneNumber) == 0 {
-		return fmt.Errorf("missing parameter: targetPhoneNumber")
+		return nil, missingParameterError("targetPhoneNumber")
 	}
 
 	reqParams := make(map[string]params)
 
-	for _, mobile := range targetPhoneNumber {
-		if strings.HasPrefix(mobile, "+") {
-			mobile = mobile[1:]
+	for _, phoneNumber := range targetPhoneNumber {
+		mobile, err := FormatPhoneNumber(GCCPAY, phoneNumber)
+		if err != nil {
+			return nil, err
 		}
 		randomString, err := RandStringBytesCrypto(16)
 		if err != nil {
-			return fmt.Errorf("SMS key generation failed")
+			return nil, fmt.Errorf("SMS key generation failed")
 		}
 
 		reqParams[randomString] = params{
@@ -95,7 +137,7 @@ func (c *GCCPAYClient) SendMessage(param map[string]string, targetPhoneNumber ..
 	requestBody := new(bytes.Buffer)
 	err := json.NewEncoder(requestBody).Encode(reqParams)
 	if err != nil {
-		return fmt.Errorf("SMS sending failed")
+		return nil, fmt.Errorf("SMS sending failed")
 	}
 
 	// sign
@@ -103,25 +145,53 @@ func (c *GCCPAYClient) SendMessage(param map[string]string, targetPhoneNumber ..
 
 	sign := Md5(fmt.Sprintf("%s%d%s", c.clientname, timestamp, c.secret))
 
-	reqUrl := "https://smscenter.sgate.sa/api/v1/client/sendSms"
+	reqUrl := fmt.Sprintf("%s/api/v1/client/sendSms", c.endpoint)
 
 	// send request
-	req, _ := http.NewRequest("POST", reqUrl, requestBody)
+	req, err := http.NewRequestWithContext(ctx, "POST", reqUrl, requestBody)
+	if err != nil {
+		return nil, err
+	}
 	req.Header.Set("clientname", c.clientname)
 	req.Header.Set("timestamp", fmt.Sprintf("%d", timestamp))
 	req.Header.Set("sign", sign)
 	req.Header.Set("content-type", "application/json;")
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	err = resp.Body.Close()
+	body, err := readResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	var gccpayResult GCCPAYResult
+	err = json.Unmarshal(body, &gccpayResult)
+
+	if statusErr := checkHttpStatus(GCCPAY, resp, body); statusErr != nil {
+		return nil, statusErr
+	}
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	result := newSendResult(GCCPAY)
+	status := SendStatusAccepted
+	code := gccpayResult.Code.String()
+	if code != "0" && code != "200" {
+		status = SendStatusRejected
+	}
+	for _, mobile := range targetPhoneNumber {
+		recipient := result.add(mobile, "", status)
+		recipient.Code = code
+		recipient.Message = gccpayResult.Msg
+	}
+
+	if status == SendStatusRejected {
+		return result, &SmsError{Provider: GCCPAY, Code: code, Message: gccpayResult.Msg}
 	}
 
-	return nil
+	return result, nil
 }
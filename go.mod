@@ -4,6 +4,7 @@ go 1.17
 
 require (
 	github.com/aliyun/alibaba-cloud-sdk-go v1.62.545
+	github.com/apistd/uni-go-sdk v0.0.2
 	github.com/aws/aws-sdk-go v1.45.5
 	github.com/baidubce/bce-sdk-go v0.9.156
 	github.com/google/uuid v1.3.1
@@ -12,10 +13,10 @@ require (
 	github.com/twilio/twilio-go v1.13.0
 	github.com/ucloud/ucloud-sdk-go v0.22.5
 	github.com/volcengine/volc-sdk-golang v1.0.117
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
-	github.com/apistd/uni-go-sdk v0.0.2 // indirect
 	github.com/cenkalti/backoff/v4 v4.1.2 // indirect
 	github.com/golang/mock v1.6.0 // indirect
 	github.com/google/go-querystring v1.1.0 // indirect
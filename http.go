@@ -0,0 +1,123 @@
+// Copyright 2024 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_sms_sender
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const defaultHttpTimeout = 30 * time.Second
+
+// HttpClientSetter is implemented by clients whose requests can go through a
+// caller supplied http.Client, e.g. to add proxies, mTLS or instrumentation.
+// Clients backed by an SDK that only accepts a transport use client.Transport.
+type HttpClientSetter interface {
+	SetHttpClient(client *http.Client)
+}
+
+func newDefaultHttpClient() *http.Client {
+	return &http.Client{Timeout: defaultHttpTimeout}
+}
+
+func httpClientOrDefault(client *http.Client) *http.Client {
+	if client != nil {
+		return client
+	}
+	return http.DefaultClient
+}
+
+func transportOf(client *http.Client) http.RoundTripper {
+	if client.Transport != nil {
+		return client.Transport
+	}
+	return http.DefaultTransport
+}
+
+// EndpointSetter is implemented by clients whose API endpoint can be changed,
+// e.g. to a regional mirror, an egress proxy or a local test server. The
+// endpoint is the base URL of the API, such as "https://api.example.com".
+type EndpointSetter interface {
+	SetEndpoint(endpoint string)
+}
+
+// splitEndpoint returns the scheme and host of an endpoint given either as a
+// URL or as a bare host name, the scheme defaults to https.
+func splitEndpoint(endpoint string) (string, string) {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return "https", strings.TrimSuffix(endpoint, "/")
+	}
+	return u.Scheme, u.Host
+}
+
+// readResponse reads and closes the response body.
+func readResponse(resp *http.Response) ([]byte, error) {
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
+
+// checkHttpStatus returns an error for a non-2xx response, classified by its status code.
+func checkHttpStatus(provider string, resp *http.Response, body []byte) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	var kind error
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		kind = ErrInvalidCredentials
+	case resp.StatusCode == http.StatusTooManyRequests:
+		kind = ErrRateLimited
+	case resp.StatusCode >= 500:
+		kind = ErrProviderUnavailable
+	}
+
+	message := strings.TrimSpace(string(body))
+	if message == "" {
+		message = resp.Status
+	}
+
+	return &SmsError{
+		Provider:   provider,
+		Code:       strconv.Itoa(resp.StatusCode),
+		Message:    message,
+		Kind:       kind,
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+	}
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds or as an HTTP date.
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		if delay := time.Until(date); delay > 0 {
+			return delay
+		}
+	}
+
+	return 0
+}
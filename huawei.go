@@ -16,9 +16,11 @@ package go_sms_sender
 
 import (
 	"bytes"
+	"context"
 	"crypto/sha256"
 	"crypto/tls"
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
@@ -35,17 +37,55 @@ const (
 )
 
 type HuaweiClient struct {
-	accessId   string
-	accessKey  string
-	sign       string
-	template   string
-	apiAddress string
-	sender     string
+	accessId       string
+	accessKey      string
+	sign           string
+	template       string
+	apiAddress     string
+	sender         string
+	statusCallback string
+	httpClient     *http.Client
+}
+
+type HuaweiResult struct {
+	Code        string             `json:"code"`
+	Description string             `json:"description"`
+	Result      []HuaweiSmsIdEntry `json:"result"`
+}
+
+type HuaweiSmsIdEntry struct {
+	OriginTo   string `json:"originTo"`
+	CreateTime string `json:"createTime"`
+	From       string `json:"from"`
+	SmsMsgId   string `json:"smsMsgId"`
+	Status     string `json:"status"`
+	CountryId  string `json:"countryId"`
+	Total      int    `json:"total"`
+}
+
+func init() {
+	Register(HuaweiCloud, func(config *Config) (SmsClient, error) {
+		client, err := GetHuaweiClient(config.AccessId, config.AccessKey, config.Sign, config.Template, []string{config.Endpoint, config.Sender})
+		if err != nil {
+			return nil, err
+		}
+
+		client.SetStatusCallback(config.Get("statusCallback"))
+		return client, nil
+	},
+		requiredField("accessId", "App Key"),
+		requiredField("accessKey", "App Secret"),
+		optionalField("sign", "Signature"),
+		requiredField("template", "Template ID"),
+		otherField("endpoint", "API Address", true),
+		otherField("sender", "Sender", true),
+		optionalField("statusCallback", "Status Callback URL"),
+	)
 }
 
 func GetHuaweiClient(accessId string, accessKey string, sign string, template string, other []string) (*HuaweiClient, error) {
 	if len(other) < 2 {
-		return nil, fmt.Errorf("missing parameter: apiAddress or sender")
+		return nil, missingParameterError("apiAddress or sender")
 	}
 
 	apiAddress := fmt.Sprintf("%s/sms/batchSendSms/v1", other[0])
@@ -57,33 +97,87 @@ func GetHuaweiClient(accessId string, accessKey string, sign string, template st
 		template:   template,
 		apiAddress: apiAddress,
 		sender:     other[1],
+		httpClient: &http.Client{
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+			},
+			Timeout: defaultHttpTimeout,
+		},
 	}
 
 	return huaweiClient, nil
 }
 
+func (c *HuaweiClient) SetHttpClient(httpClient *http.Client) {
+	c.httpClient = httpClient
+}
+
+// SetStatusCallback sets the URL delivery reports are posted to, see dlr.NewHuaweiHandler.
+func (c *HuaweiClient) SetStatusCallback(statusCallback string) {
+	c.statusCallback = statusCallback
+}
+
+func (c *HuaweiClient) SetEndpoint(endpoint string) {
+	c.apiAddress = fmt.Sprintf("%s/sms/batchSendSms/v1", strings.TrimSuffix(endpoint, "/"))
+}
+
 // SendMessage https://support.huaweicloud.com/intl/en-us/devg-msgsms/sms_04_0012.html
 func (c *HuaweiClient) SendMessage(param map[string]string, targetPhoneNumber ...string) error {
+	return c.SendMessageContext(context.Background(), param, targetPhoneNumber...)
+}
+
+func (c *HuaweiClient) SendMessageContext(ctx context.Context, param map[string]string, targetPhoneNumber ...string) error {
+	_, err := c.SendMessageResult(ctx, param, targetPhoneNumber...)
+	return err
+}
+
+func (c *HuaweiClient) SendMessageResult(ctx context.Context, param map[string]string, targetPhoneNumber ...string) (*SendResult, error) {
 	code, ok := param["code"]
 	if !ok {
-		return fmt.Errorf("missing parameter: code")
+		return nil, missingParameterError("code")
 	}
 
 	if len(targetPhoneNumber) == 0 {
-		return fmt.Errorf("missing parameter: targetPhoneNumber")
+		return nil, missingParameterError("targetPhoneNumber")
 	}
 
 	phoneNumbers := strings.Join(targetPhoneNumber, ",")
 	templateParas := fmt.Sprintf("[\"%s\"]", code)
 
-	body := buildRequestBody(c.sender, phoneNumbers, c.template, templateParas, "", c.sign)
+	body := buildRequestBody(c.sender, phoneNumbers, c.template, templateParas, c.statusCallback, c.sign)
 	headers := make(map[string]string)
 	headers["Content-Type"] = "application/x-www-form-urlencoded"
 	headers["Authorization"] = AUTH_HEADER_VALUE
 	headers["X-WSSE"] = buildWsseHeader(c.accessId, c.accessKey)
 
-	_, err := post(c.apiAddress, []byte(body), headers)
-	return err
+	respBody, err := post(ctx, c.httpClient, c.apiAddress, []byte(body), headers)
+	if err != nil {
+		return nil, err
+	}
+
+	var huaweiResult HuaweiResult
+	err = json.Unmarshal([]byte(respBody), &huaweiResult)
+	if err != nil {
+		return nil, err
+	}
+
+	result := newSendResult(HuaweiCloud)
+	for _, item := range huaweiResult.Result {
+		status := SendStatusAccepted
+		if item.Status != "000000" {
+			status = SendStatusRejected
+		}
+
+		recipient := result.add(item.OriginTo, item.SmsMsgId, status)
+		recipient.Code = item.Status
+		recipient.Segments = item.Total
+	}
+
+	if huaweiResult.Code != "000000" {
+		return result, fmt.Errorf("send message failed, code: %s, description: %s", huaweiResult.Code, huaweiResult.Description)
+	}
+
+	return result, nil
 }
 
 func buildRequestBody(sender, receiver, templateId, templateParas, statusCallBack, signature string) string {
@@ -112,13 +206,8 @@ func buildWsseHeader(appKey, appSecret string) string {
 	return fmt.Sprintf(WSSE_HEADER_FORMAT, appKey, passwordDigestBase64Str, nonce, cTime)
 }
 
-func post(url string, param []byte, headers map[string]string) (string, error) {
-	tr := &http.Transport{
-		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-	}
-	client := &http.Client{Transport: tr}
-
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(param))
+func post(ctx context.Context, client *http.Client, url string, param []byte, headers map[string]string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(param))
 	if err != nil {
 		return "", err
 	}
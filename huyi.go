@@ -15,10 +15,10 @@
 package go_sms_sender
 
 import (
+	"context"
 	"crypto/md5"
 	"encoding/hex"
-	"fmt"
-	"io"
+	"encoding/json"
 	"net/http"
 	"net/url"
 	"strconv"
@@ -26,20 +26,74 @@ import (
 	"time"
 )
 
+const huyiEndpoint = "http://106.ihuyi.com"
+
 type HuyiClient struct {
-	appId    string
-	appKey   string
-	template string
+	endpoint   string
+	appId      string
+	appKey     string
+	template   *MessageTemplate
+	segments   SegmentPolicy
+	httpClient *http.Client
+}
+
+// HuyiResult is the Submit response, code 2 means success.
+type HuyiResult struct {
+	Code  json.Number `json:"code"`
+	Msg   string      `json:"msg"`
+	SmsId string      `json:"smsid"`
+}
+
+// huyiErrors maps the codes of the Huyi Submit API.
+var huyiErrors = map[string]error{
+	"405":  ErrInvalidCredentials,
+	"4051": ErrInsufficientBalance,
+	"406":  ErrInvalidNumber,
+	"4030": ErrInvalidNumber,
+	"407":  ErrContentRejected,
+	"4072": ErrContentRejected,
+	"4084": ErrRateLimited,
+	"4085": ErrRateLimited,
+	"4086": ErrRateLimited,
+}
+
+func init() {
+	Register(Huyi, func(config *Config) (SmsClient, error) {
+		return GetHuyiClient(config.AccessId, config.AccessKey, config.Template)
+	},
+		requiredField("accessId", "APIID"),
+		requiredField("accessKey", "APIKEY"),
+		requiredField("template", "Template"),
+		optionalField("endpoint", "Endpoint"),
+	)
 }
 
 func GetHuyiClient(appId string, appKey string, template string) (*HuyiClient, error) {
 	return &HuyiClient{
-		appId:    appId,
-		appKey:   appKey,
-		template: template,
+		endpoint:   huyiEndpoint,
+		appId:      appId,
+		appKey:     appKey,
+		template:   NewMessageTemplate(template),
+		httpClient: newDefaultHttpClient(),
 	}, nil
 }
 
+func (hc *HuyiClient) SetHttpClient(httpClient *http.Client) {
+	hc.httpClient = httpClient
+}
+
+func (hc *HuyiClient) SetTemplate(template *MessageTemplate) {
+	hc.template = template
+}
+
+func (hc *HuyiClient) SetSegmentPolicy(policy SegmentPolicy) {
+	hc.segments = policy
+}
+
+func (hc *HuyiClient) SetEndpoint(endpoint string) {
+	hc.endpoint = strings.TrimSuffix(endpoint, "/")
+}
+
 func GetMd5String(s string) string {
 	h := md5.New()
 	h.Write([]byte(s))
@@ -47,43 +101,120 @@ func GetMd5String(s string) string {
 }
 
 func (hc *HuyiClient) SendMessage(param map[string]string, targetPhoneNumber ...string) error {
-	code, ok := param["code"]
-	if !ok {
-		return fmt.Errorf("missing parameter: code")
-	}
+	return hc.SendMessageContext(context.Background(), param, targetPhoneNumber...)
+}
+
+func (hc *HuyiClient) SendMessageContext(ctx context.Context, param map[string]string, targetPhoneNumber ...string) error {
+	_, err := hc.SendMessageResult(ctx, param, targetPhoneNumber...)
+	return err
+}
 
+func (hc *HuyiClient) SendMessageResult(ctx context.Context, param map[string]string, targetPhoneNumber ...string) (*SendResult, error) {
 	if len(targetPhoneNumber) == 0 {
-		return fmt.Errorf("missin parer: trgetPhoneNumber")
+		return nil, missingParameterError("targetPhoneNumber")
+	}
+
+	bodies, err := renderBodies(hc.template, hc.segments, param, targetPhoneNumber)
+	if err != nil {
+		return nil, err
 	}
 
 	_now := strconv.FormatInt(time.Now().Unix(), 10)
-	smsContent := fmt.Sprintf(hc.template, code)
 	v := url.Values{}
 	v.Set("account", hc.appId)
-	v.Set("content", smsContent)
 	v.Set("time", _now)
-	passwordStr := hc.appId + hc.appKey + "%s" + smsContent + _now
-	for _, mobile := range targetPhoneNumber {
-		password := fmt.Sprintf(passwordStr, mobile)
-		v.Set("password", GetMd5String(password))
+	result := newSendResult(Huyi)
+	for i, mobile := range targetPhoneNumber {
+		smsContent := bodies[i]
+		v.Set("content", smsContent)
+		v.Set("password", GetMd5String(hc.appId+hc.appKey+mobile+smsContent+_now))
 		v.Set("mobile", mobile)
 
 		body := strings.NewReader(v.Encode()) // encode form data
-		client := &http.Client{}
-		req, _ := http.NewRequest("POST", "http://106.ihuyi.com/webservice/sms.php?method=Submit&format=json", body)
+		req, err := http.NewRequestWithContext(ctx, "POST", hc.endpoint+"/webservice/sms.php?method=Submit&format=json", body)
+		if err != nil {
+			return result, err
+		}
 
 		req.Header.Set("Content-Type", "application/x-www-form-urlencoded; param=value")
 
-		resp, err := client.Do(req) // request remote
+		resp, err := hc.httpClient.Do(req) // request remote
 		if err != nil {
-			return err
+			return result, err
 		}
-		defer resp.Body.Close() // ！ close ReadCloser
-		_, err = io.ReadAll(resp.Body)
+		respBody, err := readResponse(resp)
 		if err != nil {
-			return err
+			return result, err
+		}
+		if err = checkHttpStatus(Huyi, resp, respBody); err != nil {
+			return result, err
+		}
+
+		var huyiResult HuyiResult
+		if err = json.Unmarshal(respBody, &huyiResult); err != nil {
+			return result, err
+		}
+
+		code := huyiResult.Code.String()
+		if code != "2" {
+			recipient := result.add(mobile, "", SendStatusRejected)
+			recipient.Code = code
+			recipient.Message = huyiResult.Msg
+			return result, newSmsError(Huyi, code, huyiResult.Msg, huyiErrors)
 		}
+
+		result.add(mobile, huyiResult.SmsId, SendStatusAccepted).Code = code
+	}
+
+	return result, nil
+}
+
+// HuyiNumResult is the GetNum response, Num is the message count left.
+type HuyiNumResult struct {
+	Code json.Number `json:"code"`
+	Msg  string      `json:"msg"`
+	Num  json.Number `json:"num"`
+}
+
+func (hc *HuyiClient) QueryBalance(ctx context.Context) (*Balance, error) {
+	_now := strconv.FormatInt(time.Now().Unix(), 10)
+	v := url.Values{}
+	v.Set("account", hc.appId)
+	v.Set("password", GetMd5String(hc.appId+hc.appKey+_now))
+	v.Set("time", _now)
+
+	req, err := http.NewRequestWithContext(ctx, "POST", hc.endpoint+"/webservice/sms.php?method=GetNum&format=json", strings.NewReader(v.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := hc.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := readResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+	if err = checkHttpStatus(Huyi, resp, respBody); err != nil {
+		return nil, err
+	}
+
+	var numResult HuyiNumResult
+	if err = json.Unmarshal(respBody, &numResult); err != nil {
+		return nil, err
+	}
+
+	code := numResult.Code.String()
+	if code != "2" {
+		return nil, newSmsError(Huyi, code, numResult.Msg, huyiErrors)
+	}
+
+	remaining, err := parseMessageCount(numResult.Num.String())
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	return newMessageBalance(Huyi, remaining), nil
 }
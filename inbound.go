@@ -0,0 +1,28 @@
+// Copyright 2024 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_sms_sender
+
+import "time"
+
+// InboundMessage is a message a user sent to one of our numbers (MO), such as
+// a reply to a code or an opt-out keyword. To is our number or service code.
+type InboundMessage struct {
+	Provider  string
+	MessageId string
+	From      string
+	To        string
+	Text      string
+	Timestamp time.Time
+}
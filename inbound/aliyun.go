@@ -0,0 +1,59 @@
+// Copyright 2024 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inbound
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	go_sms_sender "github.com/casdoor/go-sms-sender"
+	"github.com/casdoor/go-sms-sender/internal/providerdata"
+	"github.com/casdoor/go-sms-sender/internal/webhook"
+)
+
+type aliyunReply struct {
+	PhoneNumber string `json:"phone_number"`
+	SendTime    string `json:"send_time"`
+	Content     string `json:"content"`
+	SignName    string `json:"sign_name"`
+	DestCode    string `json:"dest_code"`
+	SequenceId  int64  `json:"sequence_id"`
+}
+
+// NewAliyunHandler handles the SmsUp messages Aliyun pushes over HTTP batch
+// push, whose URL must carry token. To is the extension code the reply was
+// sent to. client, if not nil, sends the replies.
+func NewAliyunHandler(token string, client go_sms_sender.SmsClient, onMessage MessageFunc) (*Handler, error) {
+	return newHandler(go_sms_sender.Aliyun, token, func(r *http.Request, body []byte) ([]go_sms_sender.InboundMessage, []byte, error) {
+		var payload []aliyunReply
+		if err := json.Unmarshal(body, &payload); err != nil {
+			return nil, nil, err
+		}
+
+		messages := []go_sms_sender.InboundMessage{}
+		for _, item := range payload {
+			messages = append(messages, go_sms_sender.InboundMessage{
+				MessageId: strconv.FormatInt(item.SequenceId, 10),
+				From:      item.PhoneNumber,
+				To:        item.DestCode,
+				Text:      item.Content,
+				Timestamp: webhook.ParseTime("2006-01-02 15:04:05", item.SendTime, providerdata.ChinaTime),
+			})
+		}
+
+		return messages, []byte(`{"code":0,"msg":"成功"}`), nil
+	}, client, onMessage)
+}
@@ -0,0 +1,64 @@
+// Copyright 2024 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inbound
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	go_sms_sender "github.com/casdoor/go-sms-sender"
+	"github.com/casdoor/go-sms-sender/internal/webhook"
+)
+
+const azureReceivedEvent = "Microsoft.Communication.SMSReceived"
+
+type azureReceivedMessage struct {
+	MessageId         string `json:"messageId"`
+	From              string `json:"from"`
+	To                string `json:"to"`
+	Message           string `json:"message"`
+	ReceivedTimestamp string `json:"receivedTimestamp"`
+}
+
+// NewAzureHandler handles the SMSReceived events of an Event Grid webhook
+// subscription, and answers its validation handshake. The endpoint URL must
+// carry token. client, if not nil, sends the replies.
+func NewAzureHandler(token string, client go_sms_sender.SmsClient, onMessage MessageFunc) (*Handler, error) {
+	return newHandler(go_sms_sender.AzureACS, token, func(r *http.Request, body []byte) ([]go_sms_sender.InboundMessage, []byte, error) {
+		events, ack, err := webhook.ParseEventGrid(body, azureReceivedEvent)
+		if err != nil || ack != nil {
+			return nil, ack, err
+		}
+
+		messages := []go_sms_sender.InboundMessage{}
+		for _, event := range events {
+			var data azureReceivedMessage
+			if err = json.Unmarshal(event, &data); err != nil {
+				return nil, nil, err
+			}
+
+			messages = append(messages, go_sms_sender.InboundMessage{
+				MessageId: data.MessageId,
+				From:      data.From,
+				To:        data.To,
+				Text:      data.Message,
+				Timestamp: webhook.ParseTime(time.RFC3339Nano, data.ReceivedTimestamp, time.UTC),
+			})
+		}
+
+		return messages, nil, nil
+	}, client, onMessage)
+}
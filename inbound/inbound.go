@@ -0,0 +1,110 @@
+// Copyright 2024 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package inbound receives the messages users send to our numbers (MO).
+//
+// Every provider has a constructor returning an http.Handler, which verifies
+// the request, parses its payload into go_sms_sender.InboundMessage values and
+// passes each of them to a MessageFunc. Twilio requests are verified with
+// their signature, the others with a token that must be added to the webhook
+// URL as the "token" query parameter, so a constructor given no token or
+// auth token fails with ErrMissingToken.
+package inbound
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	go_sms_sender "github.com/casdoor/go-sms-sender"
+	"github.com/casdoor/go-sms-sender/internal/webhook"
+)
+
+var (
+	ErrInvalidSignature = webhook.ErrInvalidSignature
+	ErrMissingToken     = webhook.ErrMissingToken
+	ErrNoReplyClient    = errors.New("no client to reply with")
+)
+
+// ReplyFunc sends a message back to the sender of an inbound message, with
+// the same params as SmsClient.SendMessage.
+type ReplyFunc func(ctx context.Context, param map[string]string) error
+
+// MessageFunc handles an inbound message. reply sends through the client
+// given to the handler, and returns ErrNoReplyClient without one. An error
+// makes the handler answer with a server error, so that the provider retries
+// the webhook.
+type MessageFunc func(ctx context.Context, message go_sms_sender.InboundMessage, reply ReplyFunc) error
+
+// parseFunc verifies and parses a webhook request, and returns the body
+// acknowledging it.
+type parseFunc func(r *http.Request, body []byte) ([]go_sms_sender.InboundMessage, []byte, error)
+
+// Handler is the inbound message webhook handler of a provider.
+type Handler struct {
+	handler *webhook.Handler
+}
+
+var _ http.Handler = &Handler{}
+
+func newHandler(provider string, token string, parse parseFunc, client go_sms_sender.SmsClient, onMessage MessageFunc) (*Handler, error) {
+	handler, err := webhook.New(token, handleMessages(provider, parse, client, onMessage))
+	if err != nil {
+		return nil, err
+	}
+	return &Handler{handler: handler}, nil
+}
+
+func newSignedHandler(provider string, secret string, parse parseFunc, client go_sms_sender.SmsClient, onMessage MessageFunc) (*Handler, error) {
+	handler, err := webhook.NewSigned(secret, handleMessages(provider, parse, client, onMessage))
+	if err != nil {
+		return nil, err
+	}
+	return &Handler{handler: handler}, nil
+}
+
+func handleMessages(provider string, parse parseFunc, client go_sms_sender.SmsClient, onMessage MessageFunc) webhook.HandleFunc {
+	return func(r *http.Request, body []byte) ([]byte, error) {
+		messages, ack, err := parse(r, body)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, message := range messages {
+			message.Provider = provider
+			if message.Timestamp.IsZero() {
+				message.Timestamp = time.Now()
+			}
+
+			if err = onMessage(r.Context(), message, replyFunc(client, message)); err != nil {
+				return nil, webhook.CallbackError(err)
+			}
+		}
+		return ack, nil
+	}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.handler.ServeHTTP(w, r)
+}
+
+func replyFunc(client go_sms_sender.SmsClient, message go_sms_sender.InboundMessage) ReplyFunc {
+	return func(ctx context.Context, param map[string]string) error {
+		if client == nil {
+			return ErrNoReplyClient
+		}
+		return go_sms_sender.SendMessageWithContext(ctx, client, param, message.From)
+	}
+}
@@ -0,0 +1,195 @@
+// Copyright 2024 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inbound
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	go_sms_sender "github.com/casdoor/go-sms-sender"
+)
+
+// replyClient records the recipients and codes of the replies it sends.
+type replyClient struct {
+	sent []string
+}
+
+func (c *replyClient) SendMessage(param map[string]string, targetPhoneNumber ...string) error {
+	for _, phoneNumber := range targetPhoneNumber {
+		c.sent = append(c.sent, phoneNumber+": "+param["code"])
+	}
+	return nil
+}
+
+// recorder collects the messages of a handler, replies to them with reply if
+// it is not empty, and fails them with err.
+type recorder struct {
+	messages []go_sms_sender.InboundMessage
+	reply    string
+	replyErr error
+	err      error
+}
+
+func (r *recorder) onMessage(ctx context.Context, message go_sms_sender.InboundMessage, reply ReplyFunc) error {
+	r.messages = append(r.messages, message)
+	if r.reply != "" {
+		r.replyErr = reply(ctx, map[string]string{"code": r.reply})
+	}
+	return r.err
+}
+
+// post serves a POST of body to target and returns the response.
+func post(handler http.Handler, target string, body string, header http.Header) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+	for key, values := range header {
+		r.Header[key] = values
+	}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
+
+func TestMissingToken(t *testing.T) {
+	var onMessage MessageFunc = (&recorder{}).onMessage
+	constructors := map[string]func() (*Handler, error){
+		"aliyun":  func() (*Handler, error) { return NewAliyunHandler("", nil, onMessage) },
+		"azure":   func() (*Handler, error) { return NewAzureHandler("", nil, onMessage) },
+		"infobip": func() (*Handler, error) { return NewInfobipHandler("", nil, onMessage) },
+		"tencent": func() (*Handler, error) { return NewTencentHandler("", nil, onMessage) },
+		"twilio":  func() (*Handler, error) { return NewTwilioHandler("", "https://example.com/mo", nil, onMessage) },
+	}
+
+	for name, constructor := range constructors {
+		if handler, err := constructor(); !errors.Is(err, ErrMissingToken) || handler != nil {
+			t.Errorf("%s: handler = %v, error = %v, want ErrMissingToken", name, handler, err)
+		}
+	}
+}
+
+func TestInfobipHandler(t *testing.T) {
+	const body = `{"results":[{"messageId":"m1","from":"385916242493","to":"385921004026","text":"HELP","receivedAt":"2024-03-01T10:15:00.000+0000"}]}`
+	tests := []struct {
+		name         string
+		target       string
+		body         string
+		client       bool
+		callback     error
+		wantCode     int
+		wantMessages int
+		wantSent     []string
+		wantReplyErr error
+	}{
+		{"reply", "/mo?token=secret", body, true, nil, http.StatusOK, 1, []string{"385916242493: Reply STOP to unsubscribe"}, nil},
+		{"no reply client", "/mo?token=secret", body, false, nil, http.StatusOK, 1, nil, ErrNoReplyClient},
+		{"wrong token", "/mo?token=other", body, true, nil, http.StatusForbidden, 0, nil, nil},
+		{"no token", "/mo", body, true, nil, http.StatusForbidden, 0, nil, nil},
+		{"malformed body", "/mo?token=secret", "{", true, nil, http.StatusBadRequest, 0, nil, nil},
+		{"callback error", "/mo?token=secret", body, true, errors.New("database down"), http.StatusInternalServerError, 1, []string{"385916242493: Reply STOP to unsubscribe"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := &recorder{reply: "Reply STOP to unsubscribe", err: tt.callback}
+			client := &replyClient{}
+			var smsClient go_sms_sender.SmsClient
+			if tt.client {
+				smsClient = client
+			}
+			handler, err := NewInfobipHandler("secret", smsClient, rec.onMessage)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			w := post(handler, tt.target, tt.body, nil)
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantCode, w.Body.String())
+			}
+			if len(rec.messages) != tt.wantMessages {
+				t.Fatalf("got %d messages, want %d", len(rec.messages), tt.wantMessages)
+			}
+			if !reflect.DeepEqual(client.sent, tt.wantSent) {
+				t.Errorf("replies = %v, want %v", client.sent, tt.wantSent)
+			}
+			if !errors.Is(rec.replyErr, tt.wantReplyErr) {
+				t.Errorf("reply error = %v, want %v", rec.replyErr, tt.wantReplyErr)
+			}
+		})
+	}
+}
+
+func TestAzureHandler(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         string
+		wantCode     int
+		wantBody     string
+		wantMessages []string
+	}{
+		{
+			name:     "validation handshake",
+			body:     `[{"eventType":"Microsoft.EventGrid.SubscriptionValidationEvent","data":{"validationCode":"512d38b6-c7b8-40c8-89fe-f46f9e9622b6"}}]`,
+			wantCode: http.StatusOK,
+			wantBody: `{"validationResponse":"512d38b6-c7b8-40c8-89fe-f46f9e9622b6"}`,
+		},
+		{
+			name: "received messages",
+			body: `[{"eventType":"Microsoft.Communication.SMSReceived","data":{"messageId":"m1","from":"+12065550100","to":"+12065550199","message":"STOP"}},` +
+				`{"eventType":"Microsoft.Communication.SMSDeliveryReportReceived","data":{}},` +
+				`{"eventType":"Microsoft.Communication.SMSReceived","data":{"messageId":"m2","from":"+12065550101","to":"+12065550199","message":"HELP"}}]`,
+			wantCode:     http.StatusOK,
+			wantMessages: []string{"+12065550100: STOP", "+12065550101: HELP"},
+		},
+		{
+			name:     "malformed body",
+			body:     `{"eventType":"Microsoft.Communication.SMSReceived"}`,
+			wantCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := &recorder{}
+			handler, err := NewAzureHandler("secret", nil, rec.onMessage)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			w := post(handler, "/mo?token=secret", tt.body, nil)
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantCode, w.Body.String())
+			}
+			if tt.wantBody != "" && w.Body.String() != tt.wantBody {
+				t.Errorf("body = %s, want %s", w.Body.String(), tt.wantBody)
+			}
+
+			messages := []string{}
+			for _, message := range rec.messages {
+				messages = append(messages, message.From+": "+message.Text)
+			}
+			if len(tt.wantMessages) == 0 {
+				tt.wantMessages = []string{}
+			}
+			if !reflect.DeepEqual(messages, tt.wantMessages) {
+				t.Errorf("messages = %v, want %v", messages, tt.wantMessages)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright 2024 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inbound
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	go_sms_sender "github.com/casdoor/go-sms-sender"
+	"github.com/casdoor/go-sms-sender/internal/webhook"
+)
+
+const infobipTimeLayout = "2006-01-02T15:04:05.000-0700"
+
+type infobipMessages struct {
+	Results []struct {
+		MessageId  string `json:"messageId"`
+		From       string `json:"from"`
+		To         string `json:"to"`
+		Text       string `json:"text"`
+		ReceivedAt string `json:"receivedAt"`
+	} `json:"results"`
+}
+
+// NewInfobipHandler handles the messages Infobip forwards to the URL of an
+// inbound number, which must carry token. client, if not nil, sends the
+// replies.
+func NewInfobipHandler(token string, client go_sms_sender.SmsClient, onMessage MessageFunc) (*Handler, error) {
+	return newHandler(go_sms_sender.Infobip, token, func(r *http.Request, body []byte) ([]go_sms_sender.InboundMessage, []byte, error) {
+		var payload infobipMessages
+		if err := json.Unmarshal(body, &payload); err != nil {
+			return nil, nil, err
+		}
+
+		messages := []go_sms_sender.InboundMessage{}
+		for _, result := range payload.Results {
+			messages = append(messages, go_sms_sender.InboundMessage{
+				MessageId: result.MessageId,
+				From:      result.From,
+				To:        result.To,
+				Text:      result.Text,
+				Timestamp: webhook.ParseTime(infobipTimeLayout, result.ReceivedAt, time.UTC),
+			})
+		}
+
+		return messages, nil, nil
+	}, client, onMessage)
+}
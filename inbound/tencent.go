@@ -0,0 +1,55 @@
+// Copyright 2024 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inbound
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	go_sms_sender "github.com/casdoor/go-sms-sender"
+)
+
+type tencentReply struct {
+	Extend     string `json:"extend"`
+	Mobile     string `json:"mobile"`
+	NationCode string `json:"nationcode"`
+	Sign       string `json:"sign"`
+	Text       string `json:"text"`
+	Time       int64  `json:"time"`
+}
+
+// NewTencentHandler handles the reply callbacks of Tencent Cloud SMS, whose
+// URL must carry token. To is the extend code the reply was sent to. client,
+// if not nil, sends the replies.
+func NewTencentHandler(token string, client go_sms_sender.SmsClient, onMessage MessageFunc) (*Handler, error) {
+	return newHandler(go_sms_sender.TencentCloud, token, func(r *http.Request, body []byte) ([]go_sms_sender.InboundMessage, []byte, error) {
+		var payload tencentReply
+		if err := json.Unmarshal(body, &payload); err != nil {
+			return nil, nil, err
+		}
+
+		message := go_sms_sender.InboundMessage{
+			From: "+" + payload.NationCode + payload.Mobile,
+			To:   payload.Extend,
+			Text: payload.Text,
+		}
+		if payload.Time > 0 {
+			message.Timestamp = time.Unix(payload.Time, 0)
+		}
+
+		return []go_sms_sender.InboundMessage{message}, []byte(`{"result":0,"errmsg":"OK"}`), nil
+	}, client, onMessage)
+}
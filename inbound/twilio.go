@@ -0,0 +1,43 @@
+// Copyright 2024 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inbound
+
+import (
+	"net/http"
+
+	go_sms_sender "github.com/casdoor/go-sms-sender"
+	"github.com/casdoor/go-sms-sender/internal/webhook"
+)
+
+// NewTwilioHandler handles the incoming message webhook of a Twilio number,
+// verifying the X-Twilio-Signature header with the auth token. webhookURL is
+// the webhook URL as configured in Twilio, it may be empty if the handler
+// sees the same URL. client, if not nil, sends the replies and needs a sender
+// set, see TwilioClient.SetSender.
+func NewTwilioHandler(authToken string, webhookURL string, client go_sms_sender.SmsClient, onMessage MessageFunc) (*Handler, error) {
+	return newSignedHandler(go_sms_sender.Twilio, authToken, func(r *http.Request, body []byte) ([]go_sms_sender.InboundMessage, []byte, error) {
+		form, err := webhook.ParseTwilioForm(r, body, authToken, webhookURL)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return []go_sms_sender.InboundMessage{{
+			MessageId: form.Get("MessageSid"),
+			From:      form.Get("From"),
+			To:        form.Get("To"),
+			Text:      form.Get("Body"),
+		}}, []byte("<Response></Response>"), nil
+	}, client, onMessage)
+}
@@ -0,0 +1,132 @@
+// Copyright 2024 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inbound
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// signTwilio computes the X-Twilio-Signature of form posted to callbackURL,
+// as documented by Twilio.
+func signTwilio(authToken string, callbackURL string, form url.Values) string {
+	data := callbackURL
+	keys := []string{}
+	for key := range form {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		data += key + form.Get(key)
+	}
+
+	mac := hmac.New(sha1.New, []byte(authToken))
+	mac.Write([]byte(data))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestTwilioHandler(t *testing.T) {
+	const (
+		authToken  = "auth-token"
+		webhookURL = "https://example.com/mo/twilio"
+	)
+	form := url.Values{"MessageSid": {"SM123"}, "From": {"+12065550100"}, "To": {"+12065550199"}, "Body": {"HELP"}}
+	tests := []struct {
+		name       string
+		webhookURL string
+		header     http.Header
+		signature  string
+		wantCode   int
+		wantSent   []string
+	}{
+		{
+			name:       "valid signature",
+			webhookURL: webhookURL,
+			signature:  signTwilio(authToken, webhookURL, form),
+			wantCode:   http.StatusOK,
+			wantSent:   []string{"+12065550100: Reply STOP to unsubscribe"},
+		},
+		{
+			name:      "request URL behind a TLS proxy",
+			header:    http.Header{"X-Forwarded-Proto": {"https"}},
+			signature: signTwilio(authToken, webhookURL, form),
+			wantCode:  http.StatusOK,
+			wantSent:  []string{"+12065550100: Reply STOP to unsubscribe"},
+		},
+		{
+			name:      "request URL without the proxy scheme",
+			signature: signTwilio(authToken, webhookURL, form),
+			wantCode:  http.StatusForbidden,
+		},
+		{
+			name:       "other auth token",
+			webhookURL: webhookURL,
+			signature:  signTwilio("other-token", webhookURL, form),
+			wantCode:   http.StatusForbidden,
+		},
+		{
+			name:       "other URL",
+			webhookURL: webhookURL,
+			signature:  signTwilio(authToken, webhookURL+"?from=proxy", form),
+			wantCode:   http.StatusForbidden,
+		},
+		{
+			name:       "no signature",
+			webhookURL: webhookURL,
+			wantCode:   http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := &recorder{reply: "Reply STOP to unsubscribe"}
+			client := &replyClient{}
+			handler, err := NewTwilioHandler(authToken, tt.webhookURL, client, rec.onMessage)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			header := http.Header{
+				"Content-Type":       {"application/x-www-form-urlencoded"},
+				"X-Twilio-Signature": {tt.signature},
+			}
+			for key, values := range tt.header {
+				header[key] = values
+			}
+
+			w := post(handler, "http://example.com/mo/twilio", form.Encode(), header)
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantCode, w.Body.String())
+			}
+			if tt.wantCode == http.StatusOK {
+				if got := w.Body.String(); got != "<Response></Response>" {
+					t.Errorf("body = %q, want an empty TwiML response", got)
+				}
+				if got := w.Header().Get("Content-Type"); got != "text/xml" {
+					t.Errorf("content type = %q, want text/xml", got)
+				}
+			}
+			if !reflect.DeepEqual(client.sent, tt.wantSent) {
+				t.Errorf("replies = %v, want %v", client.sent, tt.wantSent)
+			}
+		})
+	}
+}
@@ -16,6 +16,7 @@ package go_sms_sender
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -23,10 +24,12 @@ import (
 )
 
 type InfobipClient struct {
-	baseUrl  string
-	sender   string
-	apiKey   string
-	template string
+	baseUrl    string
+	sender     string
+	apiKey     string
+	template   *MessageTemplate
+	segments   SegmentPolicy
+	httpClient *http.Client
 }
 
 type InfobipConfigService struct {
@@ -53,73 +56,210 @@ type Destination struct {
 	To string `json:"to"`
 }
 
+type InfobipResponse struct {
+	BulkId       string               `json:"bulkId"`
+	Messages     []InfobipMessageInfo `json:"messages"`
+	RequestError *InfobipRequestError `json:"requestError"`
+}
+
+type InfobipMessageInfo struct {
+	MessageId string        `json:"messageId"`
+	To        string        `json:"to"`
+	Status    InfobipStatus `json:"status"`
+}
+
+type InfobipStatus struct {
+	GroupId     int    `json:"groupId"`
+	GroupName   string `json:"groupName"`
+	Id          int    `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type InfobipRequestError struct {
+	ServiceException struct {
+		MessageId string `json:"messageId"`
+		Text      string `json:"text"`
+	} `json:"serviceException"`
+}
+
+func init() {
+	Register(Infobip, func(config *Config) (SmsClient, error) {
+		return GetInfobipClient(config.AccessId, config.AccessKey, config.Template, []string{config.BaseUrl})
+	},
+		requiredField("accessId", "Sender"),
+		requiredField("accessKey", "API Key"),
+		requiredField("template", "Template"),
+		otherField("baseUrl", "Base URL", true),
+	)
+}
+
 func GetInfobipClient(sender string, apiKey string, template string, baseUrl []string) (*InfobipClient, error) {
 	if len(baseUrl) == 0 {
-		return nil, fmt.Errorf("missing parameter: baseUrl")
+		return nil, missingParameterError("baseUrl")
 	}
 
 	infobipClient := &InfobipClient{
-		baseUrl:  baseUrl[0],
-		sender:   sender,
-		apiKey:   apiKey,
-		template: template,
+		baseUrl:    baseUrl[0],
+		sender:     sender,
+		apiKey:     apiKey,
+		template:   NewMessageTemplate(template),
+		httpClient: newDefaultHttpClient(),
 	}
 
 	return infobipClient, nil
 }
 
+func (c *InfobipClient) SetHttpClient(httpClient *http.Client) {
+	c.httpClient = httpClient
+}
+
+func (c *InfobipClient) SetTemplate(template *MessageTemplate) {
+	c.template = template
+}
+
+func (c *InfobipClient) SetSegmentPolicy(policy SegmentPolicy) {
+	c.segments = policy
+}
+
+func (c *InfobipClient) SetEndpoint(endpoint string) {
+	c.baseUrl = strings.TrimSuffix(endpoint, "/")
+}
+
 func (c *InfobipClient) SendMessage(param map[string]string, targetPhoneNumber ...string) error {
-	code, ok := param["code"]
-	if !ok {
-		return fmt.Errorf("missing parameter: code")
-	}
+	return c.SendMessageContext(context.Background(), param, targetPhoneNumber...)
+}
+
+func (c *InfobipClient) SendMessageContext(ctx context.Context, param map[string]string, targetPhoneNumber ...string) error {
+	_, err := c.SendMessageResult(ctx, param, targetPhoneNumber...)
+	return err
+}
 
+func (c *InfobipClient) SendMessageResult(ctx context.Context, param map[string]string, targetPhoneNumber ...string) (*SendResult, error) {
 	if len(targetPhoneNumber) == 0 {
-		return fmt.Errorf("missin parer: trgetPhoneNumber")
+		return nil, missingParameterError("targetPhoneNumber")
 	}
 
-	mobile := targetPhoneNumber[0]
-
-	if strings.HasPrefix(mobile, "0") {
-		mobile = "886" + mobile[1:]
+	bodies, err := renderBodies(c.template, c.segments, param, targetPhoneNumber)
+	if err != nil {
+		return nil, err
 	}
-	if strings.HasPrefix(mobile, "+") {
-		mobile = mobile[1:]
+
+	// Recipients sharing a body are destinations of the same message.
+	messageData := MessageData{Messages: []Message{}}
+	messageIndex := map[string]int{}
+	phoneNumbers := map[string]string{}
+	for i, phoneNumber := range targetPhoneNumber {
+		mobile, err := FormatPhoneNumber(Infobip, phoneNumber)
+		if err != nil {
+			return nil, err
+		}
+
+		index, ok := messageIndex[bodies[i]]
+		if !ok {
+			index = len(messageData.Messages)
+			messageIndex[bodies[i]] = index
+			messageData.Messages = append(messageData.Messages, Message{From: c.sender, Text: bodies[i]})
+		}
+		messageData.Messages[index].Destinations = append(messageData.Messages[index].Destinations, Destination{To: mobile})
+		phoneNumbers[mobile] = phoneNumber
 	}
 
 	endpoint := fmt.Sprintf("%s/sms/2/text/advanced", c.baseUrl)
-	text := fmt.Sprintf(c.template, code)
-
-	messageData := MessageData{
-		Messages: []Message{
-			{
-				From: c.sender,
-				Destinations: []Destination{
-					{
-						To: mobile,
-					},
-				},
-				Text: text,
-			},
-		},
-	}
 	headers := map[string]string{
 		"Authorization": fmt.Sprintf("App %s", c.apiKey),
 		"Content-Type":  "application/json",
 	}
 
 	messageDataBytes, _ := json.Marshal(messageData)
-	req, _ := http.NewRequest("POST", endpoint, bytes.NewBuffer(messageDataBytes))
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewBuffer(messageDataBytes))
+	if err != nil {
+		return nil, err
+	}
 	for key, value := range headers {
 		req.Header.Set(key, value)
 	}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	body, err := readResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	var infobipResponse InfobipResponse
+	err = json.Unmarshal(body, &infobipResponse)
+
+	if statusErr := checkHttpStatus(Infobip, resp, body); statusErr != nil {
+		if infobipResponse.RequestError != nil {
+			exception := infobipResponse.RequestError.ServiceException
+			statusErr.(*SmsError).Message = fmt.Sprintf("%s: %s", exception.MessageId, exception.Text)
+		}
+		return nil, statusErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result := newSendResult(Infobip)
+	result.RequestId = infobipResponse.BulkId
+	errMsgs := []string{}
+	for _, message := range infobipResponse.Messages {
+		phoneNumber, ok := phoneNumbers[message.To]
+		if !ok {
+			phoneNumber = message.To
+		}
+
+		status := SendStatusAccepted
+		if message.Status.GroupName == "REJECTED" || message.Status.GroupName == "UNDELIVERABLE" {
+			status = SendStatusRejected
+			errMsgs = append(errMsgs, fmt.Sprintf("%s: %s", phoneNumber, message.Status.Description))
+		}
+
+		recipient := result.add(phoneNumber, message.MessageId, status)
+		recipient.Code = message.Status.Name
+		recipient.Message = message.Status.Description
+	}
+
+	if len(errMsgs) > 0 {
+		return result, &SmsError{Provider: Infobip, Message: strings.Join(errMsgs, "|")}
+	}
+
+	return result, nil
+}
+
+type InfobipBalance struct {
+	Balance  float64 `json:"balance"`
+	Currency string  `json:"currency"`
+}
+
+func (c *InfobipClient) QueryBalance(ctx context.Context) (*Balance, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/account/1/balance", c.baseUrl), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("App %s", c.apiKey))
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := readResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+	if err = checkHttpStatus(Infobip, resp, body); err != nil {
+		return nil, err
+	}
+
+	var balance InfobipBalance
+	if err = json.Unmarshal(body, &balance); err != nil {
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	return nil
+	return newAmountBalance(Infobip, balance.Balance, balance.Currency), nil
 }
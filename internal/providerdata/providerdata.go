@@ -0,0 +1,56 @@
+// Copyright 2024 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package providerdata holds the provider details shared by the clients and the
+// webhook handlers.
+package providerdata
+
+import "time"
+
+// ChinaTime is the time zone of the Chinese providers' timestamps.
+var ChinaTime = time.FixedZone("CST", 8*60*60)
+
+// TurkeyTime is the time zone of the Netgsm timestamps.
+var TurkeyTime = time.FixedZone("TRT", 3*60*60)
+
+// NetgsmTimeLayout is the layout of the Netgsm timestamps.
+const NetgsmTimeLayout = "02.01.2006 15:04:05"
+
+// TwilioStatuses maps the status of Twilio messages to the values of
+// go_sms_sender.DeliveryStatus.
+var TwilioStatuses = map[string]string{
+	"accepted":    "pending",
+	"scheduled":   "pending",
+	"queued":      "pending",
+	"sending":     "pending",
+	"sent":        "pending",
+	"delivered":   "delivered",
+	"read":        "delivered",
+	"undelivered": "failed",
+	"failed":      "failed",
+	"canceled":    "failed",
+}
+
+// NetgsmStatuses maps the message states of the Netgsm reports to the values
+// of go_sms_sender.DeliveryStatus.
+var NetgsmStatuses = map[string]string{
+	"0":  "pending",
+	"1":  "delivered",
+	"2":  "failed",
+	"3":  "failed",
+	"4":  "failed",
+	"11": "failed",
+	"12": "failed",
+	"13": "failed",
+}
@@ -0,0 +1,54 @@
+// Copyright 2024 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import "encoding/json"
+
+const eventGridValidationEvent = "Microsoft.EventGrid.SubscriptionValidationEvent"
+
+type eventGridEvent struct {
+	EventType string          `json:"eventType"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// ParseEventGrid parses the events posted by an Event Grid webhook
+// subscription and returns the data of those of eventType. A validation
+// handshake is answered with ack instead.
+func ParseEventGrid(body []byte, eventType string) (data []json.RawMessage, ack []byte, err error) {
+	var events []eventGridEvent
+	if err = json.Unmarshal(body, &events); err != nil {
+		return nil, nil, err
+	}
+
+	data = []json.RawMessage{}
+	for _, event := range events {
+		switch event.EventType {
+		case eventGridValidationEvent:
+			var validation struct {
+				ValidationCode string `json:"validationCode"`
+			}
+			if err = json.Unmarshal(event.Data, &validation); err != nil {
+				return nil, nil, err
+			}
+
+			ack, err = json.Marshal(map[string]string{"validationResponse": validation.ValidationCode})
+			return nil, ack, err
+		case eventType:
+			data = append(data, event.Data)
+		}
+	}
+
+	return data, nil, nil
+}
@@ -0,0 +1,77 @@
+// Copyright 2024 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"sort"
+)
+
+// ParseTwilioForm parses the form posted by Twilio and verifies its
+// X-Twilio-Signature header with the auth token. webhookURL is the webhook
+// URL as configured in Twilio, the request URL is used if it is empty.
+func ParseTwilioForm(r *http.Request, body []byte, authToken string, webhookURL string) (url.Values, error) {
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		return nil, err
+	}
+
+	callbackURL := webhookURL
+	if callbackURL == "" {
+		callbackURL = requestURL(r)
+	}
+	expected := TwilioSignature(authToken, callbackURL, form)
+	if !hmac.Equal([]byte(r.Header.Get("X-Twilio-Signature")), []byte(expected)) {
+		return nil, ErrInvalidSignature
+	}
+
+	return form, nil
+}
+
+// TwilioSignature signs the URL followed by the sorted POST parameters and
+// their values with HMAC-SHA1.
+func TwilioSignature(authToken string, callbackURL string, form url.Values) string {
+	keys := make([]string, 0, len(form))
+	for key := range form {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	mac := hmac.New(sha1.New, []byte(authToken))
+	mac.Write([]byte(callbackURL))
+	for _, key := range keys {
+		for _, value := range form[key] {
+			mac.Write([]byte(key + value))
+		}
+	}
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// requestURL rebuilds the URL the client requested, honoring the
+// X-Forwarded-Proto header of TLS terminating proxies.
+func requestURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+	return scheme + "://" + r.Host + r.URL.RequestURI()
+}
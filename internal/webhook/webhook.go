@@ -0,0 +1,135 @@
+// Copyright 2024 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package webhook implements the parts of the webhook handlers shared by the
+// dlr and inbound packages: token checks, body limits, error statuses and the
+// request formats of Twilio and Event Grid.
+package webhook
+
+import (
+	"crypto/subtle"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+)
+
+const MaxBodySize = 1 << 20
+
+var (
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	ErrMissingToken     = errors.New("webhook token or secret is required")
+)
+
+// HandleFunc verifies and parses a webhook request, passes its payload to the
+// callback of the handler and returns the body acknowledging it. Errors of
+// the callback are wrapped with CallbackError.
+type HandleFunc func(r *http.Request, body []byte) ([]byte, error)
+
+// Handler answers a verification failure with 403, a malformed request with
+// 400 and a callback error with 500, so that the provider retries.
+type Handler struct {
+	token  string
+	handle HandleFunc
+}
+
+var _ http.Handler = &Handler{}
+
+// New returns the handler of a provider verified by the token query
+// parameter, which must not be empty.
+func New(token string, handle HandleFunc) (*Handler, error) {
+	if token == "" {
+		return nil, ErrMissingToken
+	}
+
+	return &Handler{
+		token:  token,
+		handle: handle,
+	}, nil
+}
+
+// NewSigned returns the handler of a provider signing its requests with
+// secret, which handle verifies.
+func NewSigned(secret string, handle HandleFunc) (*Handler, error) {
+	if secret == "" {
+		return nil, ErrMissingToken
+	}
+
+	return &Handler{
+		handle: handle,
+	}, nil
+}
+
+type callbackError struct {
+	err error
+}
+
+func (e *callbackError) Error() string {
+	return e.err.Error()
+}
+
+func (e *callbackError) Unwrap() error {
+	return e.err
+}
+
+// CallbackError marks err as returned by the callback of a handler.
+func CallbackError(err error) error {
+	return &callbackError{err: err}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.token != "" && subtle.ConstantTimeCompare([]byte(r.URL.Query().Get("token")), []byte(h.token)) != 1 {
+		http.Error(w, ErrInvalidSignature.Error(), http.StatusForbidden)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, MaxBodySize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ack, err := h.handle(r, body)
+	if err != nil {
+		var cbErr *callbackError
+		switch {
+		case errors.Is(err, ErrInvalidSignature):
+			http.Error(w, err.Error(), http.StatusForbidden)
+		case errors.As(err, &cbErr):
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		default:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		}
+		return
+	}
+
+	switch {
+	case len(ack) > 0 && ack[0] == '{':
+		w.Header().Set("Content-Type", "application/json")
+	case len(ack) > 0 && ack[0] == '<':
+		w.Header().Set("Content-Type", "text/xml")
+	}
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(ack)
+}
+
+// ParseTime parses a provider time, returning the zero time for an empty or
+// malformed value.
+func ParseTime(layout string, value string, location *time.Location) time.Time {
+	t, err := time.ParseInLocation(layout, value, location)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
@@ -0,0 +1,214 @@
+// Copyright 2024 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_sms_sender
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SendersConfig is a configuration document describing several named senders:
+//
+//	senders:
+//	  otp:
+//	    provider: Aliyun SMS
+//	    accessId: ...
+type SendersConfig struct {
+	Senders map[string]*Config `json:"senders" yaml:"senders"`
+}
+
+// ParseConfigJSON parses and validates a JSON senders document.
+func ParseConfigJSON(data []byte) (map[string]*Config, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	var sendersConfig SendersConfig
+	err := decoder.Decode(&sendersConfig)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sms config: %w", err)
+	}
+
+	return sendersConfig.validate()
+}
+
+// ParseConfigYAML parses and validates a YAML senders document.
+func ParseConfigYAML(data []byte) (map[string]*Config, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+
+	var sendersConfig SendersConfig
+	err := decoder.Decode(&sendersConfig)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sms config: %w", err)
+	}
+
+	return sendersConfig.validate()
+}
+
+// LoadConfigFile reads a senders document, the format is chosen by the file
+// extension: .json, .yaml or .yml.
+func LoadConfigFile(path string) (map[string]*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return ParseConfigJSON(data)
+	case ".yaml", ".yml":
+		return ParseConfigYAML(data)
+	default:
+		return nil, fmt.Errorf("unsupported sms config file: %s", path)
+	}
+}
+
+// LoadConfigEnv reads a sender from environment variables named after the
+// Config fields with prefix, e.g. SMS_PROVIDER, SMS_ACCESS_ID and SMS_REGION
+// for the prefix "SMS". Fields of providers registered outside this package
+// are read the same way, e.g. SMS_API_TOKEN for a field named "apiToken".
+// SMS_TEMPLATES is a JSON object of Config.Templates, SMS_MAX_SEGMENTS an
+// integer and SMS_TRANSLITERATE a boolean.
+func LoadConfigEnv(prefix string) (*Config, error) {
+	providerEnv := envName(prefix, "provider")
+	provider, ok := os.LookupEnv(providerEnv)
+	if !ok || provider == "" {
+		return nil, fmt.Errorf("invalid sms config: %w: %s", ErrMissingParameter, providerEnv)
+	}
+
+	p, err := getProvider(provider)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sms config: %w", err)
+	}
+
+	config := &Config{Provider: provider}
+	names := []string{"accessId", "accessKey", "sign", "template"}
+	for _, field := range p.info.Fields {
+		names = append(names, field.Name)
+	}
+	for _, name := range names {
+		if value, ok := os.LookupEnv(envName(prefix, name)); ok {
+			config.Set(name, value)
+		}
+	}
+
+	err = loadPolicyEnv(prefix, config)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sms config: %w", err)
+	}
+
+	err = config.validate(p.info)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sms config: %w", err)
+	}
+
+	return config, nil
+}
+
+// loadPolicyEnv reads the Config fields that are not strings.
+func loadPolicyEnv(prefix string, config *Config) error {
+	if value, ok := os.LookupEnv(envName(prefix, "templates")); ok && value != "" {
+		err := json.Unmarshal([]byte(value), &config.Templates)
+		if err != nil {
+			return fmt.Errorf("%s: %w", envName(prefix, "templates"), err)
+		}
+	}
+
+	if value, ok := os.LookupEnv(envName(prefix, "maxSegments")); ok && value != "" {
+		maxSegments, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%s: %w", envName(prefix, "maxSegments"), err)
+		}
+		config.MaxSegments = maxSegments
+	}
+
+	if value, ok := os.LookupEnv(envName(prefix, "transliterate")); ok && value != "" {
+		transliterate, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%s: %w", envName(prefix, "transliterate"), err)
+		}
+		config.Transliterate = transliterate
+	}
+
+	return nil
+}
+
+// NewSmsClients creates a client for every named sender.
+func NewSmsClients(configs map[string]*Config) (map[string]SmsClient, error) {
+	clients := make(map[string]SmsClient, len(configs))
+	for _, name := range sortedConfigNames(configs) {
+		client, err := NewSmsClientFromConfig(configs[name])
+		if err != nil {
+			return nil, fmt.Errorf("sms sender %q: %w", name, err)
+		}
+		clients[name] = client
+	}
+
+	return clients, nil
+}
+
+func (s *SendersConfig) validate() (map[string]*Config, error) {
+	if len(s.Senders) == 0 {
+		return nil, fmt.Errorf("invalid sms config: %w: senders", ErrMissingParameter)
+	}
+
+	for _, name := range sortedConfigNames(s.Senders) {
+		config := s.Senders[name]
+		if config == nil {
+			return nil, fmt.Errorf("invalid sms config: sender %q is empty", name)
+		}
+
+		err := config.Validate()
+		if err != nil {
+			return nil, fmt.Errorf("invalid sms config: sender %q: %w", name, err)
+		}
+	}
+
+	return s.Senders, nil
+}
+
+func sortedConfigNames(configs map[string]*Config) []string {
+	names := make([]string, 0, len(configs))
+	for name := range configs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// envName turns a field name such as "accessId" into "SMS_ACCESS_ID".
+func envName(prefix string, name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		if unicode.IsUpper(r) && i > 0 {
+			b.WriteByte('_')
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+
+	if prefix == "" {
+		return b.String()
+	}
+	return strings.TrimSuffix(prefix, "_") + "_" + b.String()
+}
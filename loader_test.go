@@ -0,0 +1,269 @@
+// Copyright 2024 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_sms_sender
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testSendersYAML = `
+senders:
+  otp:
+    provider: Twilio SMS
+    accessId: AC123
+    accessKey: token
+    template: "Your code is {{code}}"
+    templates:
+      tr: "Kodunuz {{code}}"
+    maxSegments: 1
+  gateway:
+    provider: Test Gateway
+    accessId: user
+    accessKey: secret
+    extra:
+      apiToken: token
+`
+
+const testSendersJSON = `{
+  "senders": {
+    "otp": {"provider": "Twilio SMS", "accessId": "AC123", "accessKey": "token", "template": "Your code is {{code}}", "templates": {"tr": "Kodunuz {{code}}"}, "maxSegments": 1},
+    "gateway": {"provider": "Test Gateway", "accessId": "user", "accessKey": "secret", "extra": {"apiToken": "token"}}
+  }
+}`
+
+func TestParseConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		parse   func(data []byte) (map[string]*Config, error)
+		data    string
+		wantErr string
+	}{
+		{"yaml", ParseConfigYAML, testSendersYAML, ""},
+		{"json", ParseConfigJSON, testSendersJSON, ""},
+		{"yaml unknown field", ParseConfigYAML, "senders:\n  otp:\n    provider: Mock SMS\n    accessSecret: key\n", "field accessSecret not found"},
+		{"json unknown field", ParseConfigJSON, `{"senders": {"otp": {"provider": "Mock SMS", "accessSecret": "key"}}}`, `unknown field "accessSecret"`},
+		{"yaml missing field", ParseConfigYAML, "senders:\n  otp:\n    provider: Twilio SMS\n    accessId: AC123\n", `sender "otp": missing parameter: accessKey, template`},
+		{"json missing field", ParseConfigJSON, `{"senders": {"otp": {"provider": "Twilio SMS", "accessId": "AC123"}}}`, `sender "otp": missing parameter: accessKey, template`},
+		{"unknown provider", ParseConfigYAML, "senders:\n  otp:\n    provider: Unknown SMS\n", `sender "otp": unsupported provider: Unknown SMS`},
+		{"empty sender", ParseConfigJSON, `{"senders": {"otp": null}}`, `sender "otp" is empty`},
+		{"no senders", ParseConfigYAML, "senders: {}\n", "missing parameter: senders"},
+		{"invalid json", ParseConfigJSON, `{"senders": `, "invalid sms config"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configs, err := tt.parse([]byte(tt.data))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			want := map[string]*Config{
+				"otp": {
+					Provider:    Twilio,
+					AccessId:    "AC123",
+					AccessKey:   "token",
+					Template:    "Your code is {{code}}",
+					Templates:   map[string]string{"tr": "Kodunuz {{code}}"},
+					MaxSegments: 1,
+				},
+				"gateway": {
+					Provider:  testGateway,
+					AccessId:  "user",
+					AccessKey: "secret",
+					Extra:     map[string]string{"apiToken": "token"},
+				},
+			}
+			if !reflect.DeepEqual(configs, want) {
+				t.Errorf("configs = %+v, want %+v", configs, want)
+			}
+		})
+	}
+}
+
+func TestLoadConfigFile(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"senders.yaml": testSendersYAML,
+		"senders.YML":  testSendersYAML,
+		"senders.json": testSendersJSON,
+		"senders.toml": "",
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, name := range []string{"senders.yaml", "senders.YML", "senders.json"} {
+		configs, err := LoadConfigFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Errorf("LoadConfigFile(%s) error = %v", name, err)
+			continue
+		}
+		if len(configs) != 2 || configs["otp"].Provider != Twilio {
+			t.Errorf("LoadConfigFile(%s) = %+v", name, configs)
+		}
+	}
+
+	if _, err := LoadConfigFile(filepath.Join(dir, "senders.toml")); err == nil || !strings.Contains(err.Error(), "unsupported sms config file") {
+		t.Errorf("toml error = %v, want unsupported sms config file", err)
+	}
+	if _, err := LoadConfigFile(filepath.Join(dir, "missing.yaml")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("missing file error = %v, want os.ErrNotExist", err)
+	}
+}
+
+func TestLoadConfigEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    *Config
+		wantErr string
+	}{
+		{
+			name: "built-in provider",
+			env: map[string]string{
+				"SMS_PROVIDER":      AmazonSNS,
+				"SMS_ACCESS_ID":     "id",
+				"SMS_ACCESS_KEY":    "key",
+				"SMS_TEMPLATE":      "Your code is %s",
+				"SMS_REGION":        "us-east-1",
+				"SMS_TEMPLATES":     `{"tr": "Kodunuz %s"}`,
+				"SMS_MAX_SEGMENTS":  "2",
+				"SMS_TRANSLITERATE": "true",
+				"SMS_APP_ID":        "not a field of the provider",
+			},
+			want: &Config{
+				Provider:      AmazonSNS,
+				AccessId:      "id",
+				AccessKey:     "key",
+				Template:      "Your code is %s",
+				Region:        "us-east-1",
+				Templates:     map[string]string{"tr": "Kodunuz %s"},
+				MaxSegments:   2,
+				Transliterate: true,
+			},
+		},
+		{
+			name: "registered provider",
+			env:  map[string]string{"SMS_PROVIDER": testGateway, "SMS_ACCESS_ID": "user", "SMS_ACCESS_KEY": "secret", "SMS_API_TOKEN": "token"},
+			want: &Config{Provider: testGateway, AccessId: "user", AccessKey: "secret", Extra: map[string]string{"apiToken": "token"}},
+		},
+		{
+			name:    "missing provider",
+			env:     map[string]string{"SMS_ACCESS_ID": "id"},
+			wantErr: "missing parameter: SMS_PROVIDER",
+		},
+		{
+			name:    "unknown provider",
+			env:     map[string]string{"SMS_PROVIDER": "Unknown SMS"},
+			wantErr: "unsupported provider: Unknown SMS",
+		},
+		{
+			name:    "missing field",
+			env:     map[string]string{"SMS_PROVIDER": AmazonSNS, "SMS_ACCESS_ID": "id", "SMS_ACCESS_KEY": "key", "SMS_TEMPLATE": "%s"},
+			wantErr: "missing parameter: region",
+		},
+		{
+			name:    "invalid max segments",
+			env:     map[string]string{"SMS_PROVIDER": MockSms, "SMS_MAX_SEGMENTS": "two"},
+			wantErr: "SMS_MAX_SEGMENTS",
+		},
+		{
+			name:    "invalid templates",
+			env:     map[string]string{"SMS_PROVIDER": MockSms, "SMS_TEMPLATES": "tr=Kodunuz"},
+			wantErr: "SMS_TEMPLATES",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range []string{"SMS_PROVIDER", "SMS_ACCESS_ID", "SMS_ACCESS_KEY", "SMS_TEMPLATE", "SMS_REGION", "SMS_TEMPLATES", "SMS_MAX_SEGMENTS", "SMS_TRANSLITERATE", "SMS_APP_ID", "SMS_API_TOKEN"} {
+				t.Setenv(name, "")
+				os.Unsetenv(name)
+			}
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+
+			config, err := LoadConfigEnv("SMS")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(config, tt.want) {
+				t.Errorf("config = %+v, want %+v", config, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnvName(t *testing.T) {
+	tests := []struct {
+		prefix string
+		name   string
+		want   string
+	}{
+		{"SMS", "accessId", "SMS_ACCESS_ID"},
+		{"SMS_", "provider", "SMS_PROVIDER"},
+		{"", "smsAccount", "SMS_ACCOUNT"},
+		{"OTP_SMS", "apiToken", "OTP_SMS_API_TOKEN"},
+	}
+
+	for _, tt := range tests {
+		if got := envName(tt.prefix, tt.name); got != tt.want {
+			t.Errorf("envName(%q, %q) = %q, want %q", tt.prefix, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestNewSmsClients(t *testing.T) {
+	configs, err := ParseConfigYAML([]byte(testSendersYAML))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clients, err := NewSmsClients(configs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := clients["otp"].(*TwilioClient); !ok {
+		t.Errorf("otp = %T, want *TwilioClient", clients["otp"])
+	}
+	if _, ok := clients["gateway"].(*testGatewayClient); !ok {
+		t.Errorf("gateway = %T, want *testGatewayClient", clients["gateway"])
+	}
+
+	configs["mock"] = &Config{Provider: MockSms, Endpoint: "https://sms.example.com"}
+	if _, err := NewSmsClients(configs); err == nil || !strings.Contains(err.Error(), `sms sender "mock"`) {
+		t.Errorf("error = %v, want it to name the mock sender", err)
+	}
+}
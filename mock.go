@@ -14,14 +14,40 @@
 
 package go_sms_sender
 
+import (
+	"context"
+	"net/http"
+)
+
 type Mocker struct{}
 
-var _ SmsClient = &Mocker{}
+var _ ResultSmsClient = &Mocker{}
+
+func init() {
+	Register(MockSms, func(config *Config) (SmsClient, error) {
+		return NewMocker(config.AccessId, config.AccessKey, config.Sign, config.Template, []string{})
+	})
+}
 
 func NewMocker(accessId, accessKey, sign, templateId string, smsAccount []string) (*Mocker, error) {
 	return &Mocker{}, nil
 }
 
+func (m *Mocker) SetHttpClient(httpClient *http.Client) {}
+
 func (m *Mocker) SendMessage(param map[string]string, targetPhoneNumber ...string) error {
-	return nil
+	return m.SendMessageContext(context.Background(), param, targetPhoneNumber...)
+}
+
+func (m *Mocker) SendMessageContext(ctx context.Context, param map[string]string, targetPhoneNumber ...string) error {
+	_, err := m.SendMessageResult(ctx, param, targetPhoneNumber...)
+	return err
+}
+
+func (m *Mocker) SendMessageResult(ctx context.Context, param map[string]string, targetPhoneNumber ...string) (*SendResult, error) {
+	result := newSendResult(MockSms)
+	for _, phoneNumber := range targetPhoneNumber {
+		result.add(phoneNumber, "", SendStatusAccepted)
+	}
+	return result, nil
 }
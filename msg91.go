@@ -15,6 +15,7 @@
 package go_sms_sender
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -22,46 +23,97 @@ import (
 	"strings"
 )
 
+const msg91Endpoint = "https://control.msg91.com"
+
 type Msg91Client struct {
+	endpoint   string
 	authKey    string
 	senderId   string
 	templateId string
+	httpClient *http.Client
+}
+
+// Msg91Response is the flow API response, Message holds the request ID on success
+// and the error description otherwise.
+type Msg91Response struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+func init() {
+	Register(Msg91, func(config *Config) (SmsClient, error) {
+		return GetMsg91Client(config.AccessId, config.AccessKey, config.Template)
+	},
+		requiredField("accessId", "Sender ID"),
+		requiredField("accessKey", "Auth Key"),
+		requiredField("template", "Template ID"),
+		optionalField("endpoint", "Endpoint"),
+	)
 }
 
 func GetMsg91Client(senderId string, authKey string, templateId string) (*Msg91Client, error) {
 	msg91Client := &Msg91Client{
+		endpoint:   msg91Endpoint,
 		authKey:    authKey,
 		senderId:   senderId,
 		templateId: templateId,
+		httpClient: newDefaultHttpClient(),
 	}
 
 	return msg91Client, nil
 }
 
+func (m *Msg91Client) SetHttpClient(httpClient *http.Client) {
+	m.httpClient = httpClient
+}
+
+func (m *Msg91Client) SetEndpoint(endpoint string) {
+	m.endpoint = strings.TrimSuffix(endpoint, "/")
+}
+
 func (m *Msg91Client) SendMessage(param map[string]string, targetPhoneNumber ...string) error {
+	return m.SendMessageContext(context.Background(), param, targetPhoneNumber...)
+}
+
+func (m *Msg91Client) SendMessageContext(ctx context.Context, param map[string]string, targetPhoneNumber ...string) error {
+	_, err := m.SendMessageResult(ctx, param, targetPhoneNumber...)
+	return err
+}
+
+func (m *Msg91Client) SendMessageResult(ctx context.Context, param map[string]string, targetPhoneNumber ...string) (*SendResult, error) {
 	if len(targetPhoneNumber) == 0 {
-		return fmt.Errorf("missing parameter: targetPhoneNumber")
+		return nil, missingParameterError("targetPhoneNumber")
 	}
 
-	url := "https://control.msg91.com/api/v5/flow/"
+	url := fmt.Sprintf("%s/api/v5/flow/", m.endpoint)
 
-	for _, mobile := range targetPhoneNumber {
-		if strings.HasPrefix(mobile, "+") {
-			mobile = mobile[1:]
+	result := newSendResult(Msg91)
+	for _, phoneNumber := range targetPhoneNumber {
+		mobile, err := FormatPhoneNumber(Msg91, phoneNumber)
+		if err != nil {
+			result.add(phoneNumber, "", SendStatusRejected).Message = err.Error()
+			return result, err
 		}
 
 		payload, err := buildPayload(m.templateId, m.senderId, "0", mobile, param)
 		if err != nil {
-			return fmt.Errorf("SMS build payload failed: %v", err)
+			return result, fmt.Errorf("SMS build payload failed: %v", err)
 		}
 
-		err = postMsg91SendRequest(url, strings.NewReader(payload), m.authKey)
+		msg91Response, err := postMsg91SendRequest(ctx, m.httpClient, url, strings.NewReader(payload), m.authKey)
 		if err != nil {
-			return fmt.Errorf("send message failed: %v", err)
+			if msg91Response != nil {
+				recipient := result.add(phoneNumber, "", SendStatusRejected)
+				recipient.Code = msg91Response.Type
+				recipient.Message = msg91Response.Message
+			}
+			return result, fmt.Errorf("send message failed: %w", err)
 		}
+
+		result.add(phoneNumber, msg91Response.Message, SendStatusAccepted).Code = msg91Response.Type
 	}
 
-	return nil
+	return result, nil
 }
 
 func buildPayload(templateId, senderId, shortURL, mobiles string, variables map[string]string) (string, error) {
@@ -84,19 +136,79 @@ func buildPayload(templateId, senderId, shortURL, mobiles string, variables map[
 	return string(jsonData), nil
 }
 
-func postMsg91SendRequest(url string, payload io.Reader, authKey string) error {
-	req, _ := http.NewRequest("POST", url, payload)
+func postMsg91SendRequest(ctx context.Context, client *http.Client, url string, payload io.Reader, authKey string) (*Msg91Response, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", url, payload)
+	if err != nil {
+		return nil, err
+	}
 
 	req.Header.Add("accept", "application/json")
 	req.Header.Add("content-type", "application/json")
 	req.Header.Add("authkey", authKey)
 
-	res, _ := http.DefaultClient.Do(req)
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
 
-	err := res.Body.Close()
+	body, err := readResponse(res)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	var msg91Response Msg91Response
+	err = json.Unmarshal(body, &msg91Response)
+
+	if statusErr := checkHttpStatus(Msg91, res, body); statusErr != nil {
+		if msg91Response.Message != "" {
+			statusErr.(*SmsError).Message = msg91Response.Message
+		}
+		return &msg91Response, statusErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if msg91Response.Type != "success" {
+		return &msg91Response, &SmsError{Provider: Msg91, Code: msg91Response.Type, Message: msg91Response.Message}
+	}
+
+	return &msg91Response, nil
+}
+
+// QueryBalance queries the credits left on the transactional route, used by
+// the flow API. The answer is the bare count, or a JSON error.
+func (m *Msg91Client) QueryBalance(ctx context.Context) (*Balance, error) {
+	url := fmt.Sprintf("%s/api/balance.php?authkey=%s&type=4", m.endpoint, m.authKey)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := m.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := readResponse(res)
+	if err != nil {
+		return nil, err
+	}
+	if err = checkHttpStatus(Msg91, res, body); err != nil {
+		return nil, err
+	}
+
+	remaining, err := parseMessageCount(string(body))
+	if err != nil {
+		var errorResponse struct {
+			Msg     string `json:"msg"`
+			MsgType string `json:"msgType"`
+		}
+		message := strings.TrimSpace(string(body))
+		if json.Unmarshal(body, &errorResponse) == nil && errorResponse.Msg != "" {
+			message = errorResponse.Msg
+		}
+		return nil, &SmsError{Provider: Msg91, Code: errorResponse.MsgType, Message: message}
 	}
 
-	return nil
+	return newMessageBalance(Msg91, remaining), nil
 }
@@ -16,18 +16,28 @@ package go_sms_sender
 
 import (
 	"bytes"
+	"context"
 	"encoding/xml"
-	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/casdoor/go-sms-sender/internal/providerdata"
+	"github.com/casdoor/go-sms-sender/phone"
 )
 
+const netgsmEndpoint = "https://api.netgsm.com.tr"
+
 type NetgsmClient struct {
+	endpoint   string
 	accessId   string
 	accessKey  string
 	sign       string
 	template   string
+	segments   SegmentPolicy
 	httpClient *http.Client
 }
 
@@ -37,21 +47,72 @@ type NetgsmResponse struct {
 	Error string `xml:"main>error"`
 }
 
+// netgsmErrors maps the codes of the Netgsm OTP API.
+var netgsmErrors = map[string]error{
+	"20":  ErrContentRejected,
+	"30":  ErrInvalidCredentials,
+	"40":  ErrContentRejected,
+	"60":  ErrInsufficientBalance,
+	"70":  ErrMissingParameter,
+	"80":  ErrRateLimited,
+	"100": ErrProviderUnavailable,
+}
+
+func init() {
+	Register(Netgsm, func(config *Config) (SmsClient, error) {
+		return GetNetgsmClient(config.AccessId, config.AccessKey, config.Sign, config.Template)
+	},
+		requiredField("accessId", "Usercode"),
+		requiredField("accessKey", "Password"),
+		requiredField("sign", "Message Header"),
+		requiredField("template", "Message"),
+		optionalField("endpoint", "Endpoint"),
+	)
+}
+
 func GetNetgsmClient(accessId, accessKey, sign, template string) (*NetgsmClient, error) {
 	return &NetgsmClient{
+		endpoint:   netgsmEndpoint,
 		accessId:   accessId,
 		accessKey:  accessKey,
 		sign:       sign,
 		template:   template,
-		httpClient: &http.Client{},
+		httpClient: newDefaultHttpClient(),
 	}, nil
 }
 
+func (c *NetgsmClient) SetHttpClient(httpClient *http.Client) {
+	c.httpClient = httpClient
+}
+
+func (c *NetgsmClient) SetSegmentPolicy(policy SegmentPolicy) {
+	c.segments = policy
+}
+
+func (c *NetgsmClient) SetEndpoint(endpoint string) {
+	c.endpoint = strings.TrimSuffix(endpoint, "/")
+}
+
 func (c *NetgsmClient) SendMessage(param map[string]string, targetPhoneNumber ...string) error {
+	return c.SendMessageContext(context.Background(), param, targetPhoneNumber...)
+}
+
+func (c *NetgsmClient) SendMessageContext(ctx context.Context, param map[string]string, targetPhoneNumber ...string) error {
+	_, err := c.SendMessageResult(ctx, param, targetPhoneNumber...)
+	return err
+}
+
+func (c *NetgsmClient) SendMessageResult(ctx context.Context, param map[string]string, targetPhoneNumber ...string) (*SendResult, error) {
 	if len(targetPhoneNumber) == 0 {
-		return fmt.Errorf("missing parameter: targetPhoneNumber")
+		return nil, missingParameterError("targetPhoneNumber")
+	}
+
+	message, err := c.segments.Apply(c.template)
+	if err != nil {
+		return nil, err
 	}
 
+	result := newSendResult(Netgsm)
 	for _, phoneNumber := range targetPhoneNumber {
 		data := fmt.Sprintf(`
 <mainbody>
@@ -66,31 +127,157 @@ func (c *NetgsmClient) SendMessage(param map[string]string, targetPhoneNumber ..
        </msg>
        <no>%s</no>
    </body>
-</mainbody>`, c.accessId, c.accessKey, c.sign, c.template, phoneNumber)
+</mainbody>`, c.accessId, c.accessKey, c.sign, message, phoneNumber)
 
 		headers := map[string]string{
 			"Content-Type": "application/xml",
 		}
 
-		respBody, err := c.postXML("https://api.netgsm.com.tr/sms/send/otp", data, headers)
+		respBody, err := c.postXML(ctx, c.endpoint+"/sms/send/otp", data, headers)
 		if err != nil {
-			return err
+			return result, err
 		}
 
 		var netgsmResponse NetgsmResponse
 		if err := xml.Unmarshal([]byte(respBody), &netgsmResponse); err != nil {
-			return err
+			return result, err
 		}
 
 		if netgsmResponse.Code != "0" {
-			return errors.New(netgsmResponse.Error)
+			recipient := result.add(phoneNumber, "", SendStatusRejected)
+			recipient.Code = netgsmResponse.Code
+			recipient.Message = netgsmResponse.Error
+			return result, newSmsError(Netgsm, netgsmResponse.Code, netgsmResponse.Error, netgsmErrors)
+		}
+
+		result.add(phoneNumber, netgsmResponse.JobID, SendStatusAccepted).Code = netgsmResponse.Code
+	}
+	return result, nil
+}
+
+// QueryStatus queries the report API by the job ID of the send. The answer
+// has a line per recipient with the number, state, operator, length, date,
+// time and error code, and the line of query.PhoneNumber is used if it is set.
+func (c *NetgsmClient) QueryStatus(ctx context.Context, query StatusQuery) (*DeliveryReport, error) {
+	if query.MessageId == "" {
+		return nil, missingParameterError("messageId")
+	}
+
+	data := fmt.Sprintf(`<?xml version="1.0"?>
+<mainbody>
+   <header>
+       <usercode>%s</usercode>
+       <password>%s</password>
+       <bulkid>%s</bulkid>
+       <type>0</type>
+       <status>100</status>
+       <version>2</version>
+   </header>
+</mainbody>`, c.accessId, c.accessKey, query.MessageId)
+
+	respBody, err := c.postXML(ctx, c.endpoint+"/sms/report", data, map[string]string{"Content-Type": "application/xml"})
+	if err != nil {
+		return nil, err
+	}
+
+	respBody = strings.TrimSpace(respBody)
+	switch respBody {
+	case "60":
+		return nil, ErrMessageNotFound
+	case "30", "70", "100", "101":
+		return nil, newSmsError(Netgsm, respBody, "report query failed", netgsmErrors)
+	}
+
+	for _, line := range strings.Split(respBody, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || query.PhoneNumber != "" && !sameTurkishNumber(fields[0], query.PhoneNumber) {
+			continue
+		}
+
+		status, ok := providerdata.NetgsmStatuses[fields[1]]
+		if !ok {
+			status = string(DeliveryStatusUnknown)
+		}
+
+		report := &DeliveryReport{
+			Provider:  Netgsm,
+			MessageId: query.MessageId,
+			Recipient: fields[0],
+			Status:    DeliveryStatus(status),
 		}
+		if len(fields) >= 6 {
+			report.Timestamp, _ = time.ParseInLocation(providerdata.NetgsmTimeLayout, fields[4]+" "+fields[5], providerdata.TurkeyTime)
+		}
+		if report.Status == DeliveryStatusFailed {
+			report.ErrorCode = fields[1]
+			if len(fields) >= 7 {
+				report.ErrorCode = fields[6]
+			}
+		}
+		return report, nil
+	}
+
+	return nil, ErrMessageNotFound
+}
+
+// QueryBalance queries the credit of the account in Turkish lira, the answer
+// is the result code and the amount with a decimal comma, e.g. "00 156,758".
+func (c *NetgsmClient) QueryBalance(ctx context.Context) (*Balance, error) {
+	data := fmt.Sprintf(`<?xml version="1.0"?>
+<mainbody>
+   <header>
+       <usercode>%s</usercode>
+       <password>%s</password>
+       <stip>2</stip>
+   </header>
+</mainbody>`, c.accessId, c.accessKey)
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.endpoint+"/balance/list/xml", strings.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/xml")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := readResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+	if err = checkHttpStatus(Netgsm, resp, body); err != nil {
+		return nil, err
+	}
+
+	respBody := string(body)
+	fields := strings.Fields(respBody)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("unexpected netgsm balance response: %q", respBody)
+	}
+	if fields[0] != "00" || len(fields) < 2 {
+		return nil, newSmsError(Netgsm, fields[0], "balance query failed", netgsmErrors)
+	}
+
+	amount, err := strconv.ParseFloat(strings.Replace(strings.Replace(fields[1], ".", "", -1), ",", ".", 1), 64)
+	if err != nil {
+		return nil, err
+	}
+
+	return newAmountBalance(Netgsm, amount, "TRY"), nil
+}
+
+func sameTurkishNumber(a string, b string) bool {
+	numberA, err := phone.Parse(a, "TR")
+	if err != nil {
+		return a == b
 	}
-	return nil
+	numberB, err := phone.Parse(b, "TR")
+	return err == nil && numberA.E164() == numberB.E164()
 }
 
-func (c *NetgsmClient) postXML(url, xmlData string, headers map[string]string) (string, error) {
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer([]byte(xmlData)))
+func (c *NetgsmClient) postXML(ctx context.Context, url, xmlData string, headers map[string]string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer([]byte(xmlData)))
 	if err != nil {
 		return "", err
 	}
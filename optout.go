@@ -0,0 +1,268 @@
+// Copyright 2024 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_sms_sender
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/casdoor/go-sms-sender/phone"
+)
+
+var ErrOptedOut = errors.New("recipient opted out")
+
+// OptOutError is returned for a send to opted-out numbers through an
+// OptOutClient, the message was sent to the other targets.
+type OptOutError struct {
+	PhoneNumbers []string
+}
+
+func (e *OptOutError) Error() string {
+	return fmt.Sprintf("recipients opted out: %s", strings.Join(e.PhoneNumbers, ", "))
+}
+
+func (e *OptOutError) Unwrap() error {
+	return ErrOptedOut
+}
+
+// OptOutAction is the action requested by an inbound keyword.
+type OptOutAction int
+
+const (
+	OptOutActionNone OptOutAction = iota
+	// OptOutActionStop adds the sender to the suppression list.
+	OptOutActionStop
+	// OptOutActionStart removes the sender from the suppression list.
+	OptOutActionStart
+)
+
+// The default keywords, matched against the whole message ignoring case,
+// spaces and punctuation.
+var (
+	DefaultOptOutKeywords = []string{"STOP", "STOPALL", "UNSUBSCRIBE", "CANCEL", "END", "QUIT", "退订", "TD"}
+	DefaultOptInKeywords  = []string{"START", "UNSTOP"}
+)
+
+// OptOutStore keeps the suppression list of an OptOutClient, keyed by E.164
+// phone number, so that several processes can share it.
+type OptOutStore interface {
+	IsOptedOut(ctx context.Context, phoneNumber string) (bool, error)
+	OptOut(ctx context.Context, phoneNumber string) error
+	OptIn(ctx context.Context, phoneNumber string) error
+}
+
+// OptOutPolicy configures an OptOutClient. DefaultRegion is the region of
+// national numbers, e.g. "CN" for inbound messages reporting 13800138000.
+// Store defaults to a new MemoryOptOutStore, and the keywords to
+// DefaultOptOutKeywords and DefaultOptInKeywords.
+type OptOutPolicy struct {
+	Store          OptOutStore
+	DefaultRegion  string
+	OptOutKeywords []string
+	OptInKeywords  []string
+}
+
+// OptOutClient does not send to numbers of its suppression list. They are
+// reported as rejected and the message is sent to the others, the error is
+// then an *OptOutError.
+type OptOutClient struct {
+	client SmsClient
+	policy OptOutPolicy
+}
+
+var _ ResultSmsClient = &OptOutClient{}
+
+func NewOptOutClient(client SmsClient, policy OptOutPolicy) *OptOutClient {
+	if policy.Store == nil {
+		policy.Store = NewMemoryOptOutStore()
+	}
+	if policy.OptOutKeywords == nil {
+		policy.OptOutKeywords = DefaultOptOutKeywords
+	}
+	if policy.OptInKeywords == nil {
+		policy.OptInKeywords = DefaultOptInKeywords
+	}
+
+	return &OptOutClient{
+		client: client,
+		policy: policy,
+	}
+}
+
+func (c *OptOutClient) SendMessage(param map[string]string, targetPhoneNumber ...string) error {
+	return c.SendMessageContext(context.Background(), param, targetPhoneNumber...)
+}
+
+func (c *OptOutClient) SendMessageContext(ctx context.Context, param map[string]string, targetPhoneNumber ...string) error {
+	_, err := c.SendMessageResult(ctx, param, targetPhoneNumber...)
+	return err
+}
+
+func (c *OptOutClient) SendMessageResult(ctx context.Context, param map[string]string, targetPhoneNumber ...string) (*SendResult, error) {
+	allowed := []string{}
+	var suppressed []RecipientResult
+	var optOutErr *OptOutError
+	for _, phoneNumber := range targetPhoneNumber {
+		optedOut, err := c.policy.Store.IsOptedOut(ctx, c.key(phoneNumber))
+		if err != nil {
+			return nil, err
+		}
+		if !optedOut {
+			allowed = append(allowed, phoneNumber)
+			continue
+		}
+
+		if optOutErr == nil {
+			optOutErr = &OptOutError{}
+		}
+		optOutErr.PhoneNumbers = append(optOutErr.PhoneNumbers, phoneNumber)
+		suppressed = append(suppressed, RecipientResult{
+			PhoneNumber: phoneNumber,
+			Status:      SendStatusRejected,
+			Message:     ErrOptedOut.Error(),
+		})
+	}
+
+	if optOutErr == nil {
+		return SendMessageWithResult(ctx, c.client, param, targetPhoneNumber...)
+	}
+
+	result := &SendResult{Recipients: []RecipientResult{}}
+	if len(allowed) > 0 {
+		sendResult, err := SendMessageWithResult(ctx, c.client, param, allowed...)
+		if sendResult != nil {
+			result = sendResult
+		}
+		if err != nil {
+			result.Recipients = append(result.Recipients, suppressed...)
+			return result, err
+		}
+	}
+
+	result.Recipients = append(result.Recipients, suppressed...)
+	return result, optOutErr
+}
+
+// QueryBalance queries the balance of the wrapped client.
+func (c *OptOutClient) QueryBalance(ctx context.Context) (*Balance, error) {
+	return QueryBalance(ctx, c.client)
+}
+
+// QueryStatus queries the delivery status through the wrapped client.
+func (c *OptOutClient) QueryStatus(ctx context.Context, query StatusQuery) (*DeliveryReport, error) {
+	return QueryStatus(ctx, c.client, query)
+}
+
+// OptOut adds phoneNumber to the suppression list.
+func (c *OptOutClient) OptOut(ctx context.Context, phoneNumber string) error {
+	return c.policy.Store.OptOut(ctx, c.key(phoneNumber))
+}
+
+// OptIn removes phoneNumber from the suppression list.
+func (c *OptOutClient) OptIn(ctx context.Context, phoneNumber string) error {
+	return c.policy.Store.OptIn(ctx, c.key(phoneNumber))
+}
+
+// IsOptedOut reports whether phoneNumber is in the suppression list.
+func (c *OptOutClient) IsOptedOut(ctx context.Context, phoneNumber string) (bool, error) {
+	return c.policy.Store.IsOptedOut(ctx, c.key(phoneNumber))
+}
+
+// HandleInboundMessage updates the suppression list if message is an opt-out
+// or opt-in keyword, and returns the action taken. Confirmation replies must
+// be sent through the wrapped client, since the sender is now suppressed.
+func (c *OptOutClient) HandleInboundMessage(ctx context.Context, message InboundMessage) (OptOutAction, error) {
+	action := c.ParseKeyword(message.Text)
+	switch action {
+	case OptOutActionStop:
+		return action, c.OptOut(ctx, message.From)
+	case OptOutActionStart:
+		return action, c.OptIn(ctx, message.From)
+	}
+	return action, nil
+}
+
+// ParseKeyword returns the action requested by text, OptOutActionNone if it
+// is not one of the keywords.
+func (c *OptOutClient) ParseKeyword(text string) OptOutAction {
+	keyword := strings.ToUpper(strings.TrimFunc(text, func(r rune) bool {
+		return unicode.IsSpace(r) || unicode.IsPunct(r)
+	}))
+	if keyword == "" {
+		return OptOutActionNone
+	}
+
+	for _, k := range c.policy.OptOutKeywords {
+		if strings.ToUpper(k) == keyword {
+			return OptOutActionStop
+		}
+	}
+	for _, k := range c.policy.OptInKeywords {
+		if strings.ToUpper(k) == keyword {
+			return OptOutActionStart
+		}
+	}
+	return OptOutActionNone
+}
+
+// key is phoneNumber in E.164 format, so that the numbers of inbound messages
+// and sends match, or phoneNumber itself if it cannot be parsed.
+func (c *OptOutClient) key(phoneNumber string) string {
+	number, err := phone.Normalize(phoneNumber, c.policy.DefaultRegion)
+	if err != nil {
+		return strings.TrimSpace(phoneNumber)
+	}
+	return number
+}
+
+// MemoryOptOutStore is an OptOutStore for a single process.
+type MemoryOptOutStore struct {
+	mu       sync.Mutex
+	optedOut map[string]struct{}
+}
+
+func NewMemoryOptOutStore() *MemoryOptOutStore {
+	return &MemoryOptOutStore{
+		optedOut: map[string]struct{}{},
+	}
+}
+
+func (s *MemoryOptOutStore) IsOptedOut(ctx context.Context, phoneNumber string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, ok := s.optedOut[phoneNumber]
+	return ok, nil
+}
+
+func (s *MemoryOptOutStore) OptOut(ctx context.Context, phoneNumber string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.optedOut[phoneNumber] = struct{}{}
+	return nil
+}
+
+func (s *MemoryOptOutStore) OptIn(ctx context.Context, phoneNumber string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.optedOut, phoneNumber)
+	return nil
+}
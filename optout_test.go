@@ -0,0 +1,156 @@
+// Copyright 2024 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_sms_sender
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestOptOutClientSend(t *testing.T) {
+	a, b, c := "+8613800138000", "+8613900139000", "+8613700137000"
+	tests := []struct {
+		name           string
+		optedOut       []string
+		replies        []fakeReply
+		targets        []string
+		wantCalls      [][]string
+		wantRecipients []string
+		wantOptedOut   []string
+		wantErr        error
+	}{
+		{
+			name:           "nobody opted out",
+			targets:        []string{a, b},
+			wantCalls:      [][]string{{a, b}},
+			wantRecipients: []string{a + "  accepted", b + "  accepted"},
+		},
+		{
+			name:           "opted-out number is not sent",
+			optedOut:       []string{b},
+			targets:        []string{a, b, c},
+			wantCalls:      [][]string{{a, c}},
+			wantRecipients: []string{a + "  accepted", c + "  accepted", b + "  rejected"},
+			wantOptedOut:   []string{b},
+			wantErr:        ErrOptedOut,
+		},
+		{
+			name:           "national format opted out",
+			optedOut:       []string{"13900139000"},
+			targets:        []string{a, "+86 139 0013 9000"},
+			wantCalls:      [][]string{{a}},
+			wantRecipients: []string{a + "  accepted", "+86 139 0013 9000  rejected"},
+			wantOptedOut:   []string{"+86 139 0013 9000"},
+			wantErr:        ErrOptedOut,
+		},
+		{
+			name:           "every number opted out",
+			optedOut:       []string{a, "0086 138 0013 8000", b},
+			targets:        []string{a, b},
+			wantRecipients: []string{a + "  rejected", b + "  rejected"},
+			wantOptedOut:   []string{a, b},
+			wantErr:        ErrOptedOut,
+		},
+		{
+			name:           "send error is kept",
+			optedOut:       []string{b},
+			replies:        []fakeReply{{noResult: true, err: errUnavailable}},
+			targets:        []string{a, b},
+			wantCalls:      [][]string{{a}},
+			wantRecipients: []string{b + "  rejected"},
+			wantErr:        ErrProviderUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakeClient{replies: tt.replies}
+			optOutClient := NewOptOutClient(client, OptOutPolicy{DefaultRegion: "CN"})
+			for _, phoneNumber := range tt.optedOut {
+				if err := optOutClient.OptOut(context.Background(), phoneNumber); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			result, err := optOutClient.SendMessageResult(context.Background(), nil, tt.targets...)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			var optOutErr *OptOutError
+			if errors.As(err, &optOutErr) != (tt.wantOptedOut != nil) || optOutErr != nil && !reflect.DeepEqual(optOutErr.PhoneNumbers, tt.wantOptedOut) {
+				t.Errorf("error = %#v, want an *OptOutError for %v", err, tt.wantOptedOut)
+			}
+			if len(tt.wantCalls) == 0 {
+				tt.wantCalls = nil
+			}
+			if !reflect.DeepEqual(client.calls, tt.wantCalls) {
+				t.Errorf("calls = %v, want %v", client.calls, tt.wantCalls)
+			}
+			if got := recipientSummary(result); !reflect.DeepEqual(got, tt.wantRecipients) {
+				t.Errorf("recipients = %v, want %v", got, tt.wantRecipients)
+			}
+		})
+	}
+}
+
+func TestOptOutClientKeywords(t *testing.T) {
+	tests := []struct {
+		name         string
+		policy       OptOutPolicy
+		text         string
+		want         OptOutAction
+		wantOptedOut bool
+	}{
+		{name: "stop", text: "STOP", want: OptOutActionStop, wantOptedOut: true},
+		{name: "case and punctuation", text: "  Unsubscribe! ", want: OptOutActionStop, wantOptedOut: true},
+		{name: "chinese", text: "退订", want: OptOutActionStop, wantOptedOut: true},
+		{name: "start", text: "start", want: OptOutActionStart},
+		{name: "keyword inside text", text: "please stop", want: OptOutActionNone},
+		{name: "empty", text: "...", want: OptOutActionNone},
+		{name: "custom keywords", policy: OptOutPolicy{OptOutKeywords: []string{"iptal"}}, text: "IPTAL", want: OptOutActionStop, wantOptedOut: true},
+		{name: "custom keywords replace defaults", policy: OptOutPolicy{OptOutKeywords: []string{"iptal"}}, text: "STOP", want: OptOutActionNone},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.policy.DefaultRegion = "CN"
+			optOutClient := NewOptOutClient(&fakeClient{}, tt.policy)
+			if tt.want == OptOutActionStart {
+				if err := optOutClient.OptOut(context.Background(), "+8613800138000"); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			// the inbound sender is reported in national format
+			action, err := optOutClient.HandleInboundMessage(context.Background(), InboundMessage{From: "13800138000", Text: tt.text})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if action != tt.want {
+				t.Errorf("action = %v, want %v", action, tt.want)
+			}
+
+			optedOut, err := optOutClient.IsOptedOut(context.Background(), "+86 138 0013 8000")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if optedOut != tt.wantOptedOut {
+				t.Errorf("opted out = %v, want %v", optedOut, tt.wantOptedOut)
+			}
+		})
+	}
+}
@@ -15,39 +15,76 @@
 package go_sms_sender
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+const osonEndpoint = "https://api.osonsms.com"
+
 type OsonClient struct {
 	Endpoint         string
 	SenderID         string
 	SecretAccessHash string
 	Sign             string
 	Message          string
+
+	segments   SegmentPolicy
+	httpClient *http.Client
 }
 
 type OsonResponse struct {
-	Status        string    // ok
-	Timestamp     time.Time // 2017-07-07 16:58:12
-	TxnId         string    // f89xxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxe0b
-	MsgId         uint      // 40127
-	SmscMsgId     string    // 45f22479
-	SmscMsgStatus string    // success
-	SmscMsgParts  string    // 1
+	Status        string     // ok
+	Timestamp     string     // 2017-07-07 16:58:12, not RFC 3339
+	TxnId         string     `json:"txn_id"`          // f89xxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxe0b
+	MsgId         uint       `json:"msg_id"`          // 40127
+	SmscMsgId     string     `json:"smsc_msg_id"`     // 45f22479
+	SmscMsgStatus string     `json:"smsc_msg_status"` // success
+	SmscMsgParts  string     // 1
+	Error         *OsonError `json:"error"`
+}
+
+// OsonError is the error of a failed request.
+type OsonError struct {
+	Code int    `json:"code"`
+	Msg  string `json:"msg"`
+}
+
+// osonErrors maps the error codes of the OsonSMS API, which follow the HTTP
+// status of the failed request.
+var osonErrors = map[string]error{
+	"400": ErrMissingParameter,
+	"401": ErrInvalidCredentials,
+	"403": ErrInvalidCredentials,
+	"429": ErrRateLimited,
+	"500": ErrProviderUnavailable,
+	"502": ErrProviderUnavailable,
+	"503": ErrProviderUnavailable,
+}
+
+func init() {
+	Register(OsonSms, func(config *Config) (SmsClient, error) {
+		return GetOsonClient(config.AccessId, config.AccessKey, config.Sign, config.Template)
+	},
+		requiredField("accessId", "Login"),
+		requiredField("accessKey", "Hash"),
+		requiredField("sign", "From"),
+		optionalField("template", "Message"),
+		optionalField("endpoint", "Endpoint"),
+	)
 }
 
 func GetOsonClient(senderId, secretAccessHash, sign, message string) (*OsonClient, error) {
 	return &OsonClient{
-		Endpoint:         "https://api.osonsms.com/sendsms_v1.php",
+		Endpoint:         osonEndpoint + "/sendsms_v1.php",
 		SenderID:         senderId,
 		SecretAccessHash: secretAccessHash,
 		Sign:             sign,
@@ -55,22 +92,85 @@ func GetOsonClient(senderId, secretAccessHash, sign, message string) (*OsonClien
 	}, nil
 }
 
-func (c *OsonClient) SendMessage(param map[string]string, targetPhoneNumber ...string) (err error) {
+func (c *OsonClient) SetHttpClient(httpClient *http.Client) {
+	c.httpClient = httpClient
+}
+
+func (c *OsonClient) SetSegmentPolicy(policy SegmentPolicy) {
+	c.segments = policy
+}
+
+// SetEndpoint sets Endpoint to the send API of the server at endpoint.
+func (c *OsonClient) SetEndpoint(endpoint string) {
+	c.Endpoint = strings.TrimSuffix(endpoint, "/") + "/sendsms_v1.php"
+}
+
+func (c *OsonClient) SendMessage(param map[string]string, targetPhoneNumber ...string) error {
+	return c.SendMessageContext(context.Background(), param, targetPhoneNumber...)
+}
+
+func (c *OsonClient) SendMessageContext(ctx context.Context, param map[string]string, targetPhoneNumber ...string) error {
+	_, err := c.SendMessageResult(ctx, param, targetPhoneNumber...)
+	return err
+}
+
+func (c *OsonClient) SendMessageResult(ctx context.Context, param map[string]string, targetPhoneNumber ...string) (*SendResult, error) {
+	if len(targetPhoneNumber) == 0 {
+		return nil, missingParameterError("targetPhoneNumber")
+	}
+
 	// Init http client for make request to sms center. Set a timeout of 25+
 	// seconds to ensure that the response from the SMS center has been
 	// processed.
-	client := &http.Client{
-		Timeout: 20 * time.Second,
+	client := c.httpClient
+	if client == nil {
+		client = &http.Client{
+			Timeout: 20 * time.Second,
+		}
 	}
 
+	message := c.Message + param["code"]
 	if c.Message == "" {
-		c.Message = fmt.Sprintf("Hello. Your authorization code: %s", param["code"])
-	} else {
-		c.Message += param["code"]
+		message = fmt.Sprintf("Hello. Your authorization code: %s", param["code"])
+	}
+	message, err := c.segments.Apply(message)
+	if err != nil {
+		return nil, err
+	}
+
+	sendResult := newSendResult(OsonSms)
+	for _, phoneNumber := range targetPhoneNumber {
+		txnID := uuid.New().String()
+		if sendResult.RequestId == "" {
+			sendResult.RequestId = txnID
+		}
+
+		result, body, err := c.send(ctx, client, txnID, phoneNumber, message)
+		if err != nil {
+			return sendResult, err
+		}
+
+		if result.Status != "ok" {
+			code, errMsg := result.Status, string(body)
+			if result.Error != nil {
+				code, errMsg = strconv.Itoa(result.Error.Code), result.Error.Msg
+			}
+			recipient := sendResult.add(phoneNumber, "", SendStatusRejected)
+			recipient.Code = code
+			recipient.Message = errMsg
+			return sendResult, newSmsError(OsonSms, code, errMsg, osonErrors)
+		}
+
+		recipient := sendResult.add(phoneNumber, strconv.FormatUint(uint64(result.MsgId), 10), SendStatusAccepted)
+		recipient.Code = result.SmscMsgStatus
 	}
 
-	txnID := uuid.New()
-	buildStrHash := strings.Join([]string{txnID.String(), c.SenderID, c.Sign, targetPhoneNumber[0], c.SecretAccessHash}, ";")
+	return sendResult, nil
+}
+
+// send sends message to a single number, the API takes one per request.
+func (c *OsonClient) send(ctx context.Context, client *http.Client, txnID string, phoneNumber string, message string) (*OsonResponse, []byte, error) {
+	buildStrHash := strings.Join([]string{txnID, c.SenderID, c.Sign, phoneNumber, c.SecretAccessHash}, ";")
 
 	hash := sha256.New()
 	hash.Write([]byte(buildStrHash))
@@ -79,42 +179,41 @@ func (c *OsonClient) SendMessage(param map[string]string, targetPhoneNumber ...s
 
 	urlLink, err := url.Parse(c.Endpoint)
 	if err != nil {
-		return
+		return nil, nil, err
 	}
 
 	urlParams := url.Values{}
 	urlParams.Add("from", c.Sign)
-	urlParams.Add("phone_number", targetPhoneNumber[0])
-	urlParams.Add("msg", c.Message)
+	urlParams.Add("phone_number", phoneNumber)
+	urlParams.Add("msg", message)
 	urlParams.Add("str_hash", strHash)
-	urlParams.Add("txn_id", txnID.String())
+	urlParams.Add("txn_id", txnID)
 	urlParams.Add("login", c.SenderID)
 
 	urlLink.RawQuery = urlParams.Encode()
 
-	request, err := http.NewRequest(http.MethodGet, urlLink.String(), nil)
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, urlLink.String(), nil)
 	if err != nil {
-		return
+		return nil, nil, err
 	}
 
 	resp, err := client.Do(request)
 	if err != nil {
-		return
+		return nil, nil, err
 	}
 
-	resultBytes, err := io.ReadAll(resp.Body)
+	resultBytes, err := readResponse(resp)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 
 	var result OsonResponse
 	if err = json.Unmarshal(resultBytes, &result); err != nil {
-		return
-	}
-
-	if result.Status != "ok" {
-		return fmt.Errorf("sms service returned error status not 200: Status Code: %d Error: %s", resp.StatusCode, string(resultBytes))
+		if statusErr := checkHttpStatus(OsonSms, resp, resultBytes); statusErr != nil {
+			return nil, nil, statusErr
+		}
+		return nil, nil, err
 	}
 
-	return
+	return &result, resultBytes, nil
 }
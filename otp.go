@@ -0,0 +1,277 @@
+// Copyright 2024 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_sms_sender
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/casdoor/go-sms-sender/phone"
+)
+
+const (
+	defaultOtpLength      = 6
+	defaultOtpAlphabet    = "0123456789"
+	defaultOtpTTL         = 5 * time.Minute
+	defaultOtpMaxAttempts = 5
+)
+
+var (
+	ErrOtpNotFound        = errors.New("no code was sent to this number")
+	ErrOtpExpired         = errors.New("code expired")
+	ErrOtpMismatch        = errors.New("code mismatch")
+	ErrOtpTooManyAttempts = errors.New("too many attempts")
+)
+
+// OtpRecord is a sent code as kept by an OtpStore, which only sees a salted
+// hash of the code.
+type OtpRecord struct {
+	Hash      []byte
+	Salt      []byte
+	ExpiresAt time.Time
+	Attempts  int
+}
+
+// OtpStore keeps the codes of an OtpService by phone number, so that several
+// processes can share them. Update atomically calls update with the record of
+// key, or nil without record, and stores the record it returns, or deletes it
+// if nil. It returns the error of update.
+type OtpStore interface {
+	Update(ctx context.Context, key string, update func(record *OtpRecord) (*OtpRecord, error)) error
+}
+
+// OtpPolicy configures an OtpService. Zero values send 6-digit codes valid
+// for 5 minutes, which can be checked 5 times, kept in a new MemoryOtpStore.
+// DefaultRegion is the region of national phone numbers, which are keyed by
+// their E.164 format.
+type OtpPolicy struct {
+	Length        int
+	Alphabet      string
+	TTL           time.Duration
+	MaxAttempts   int
+	Store         OtpStore
+	DefaultRegion string
+}
+
+// OtpService sends one-time codes through a client and verifies them. A number
+// is locked after MaxAttempts failed verifications, counting the codes resent
+// before the previous one expired, and no new code is sent to it until the
+// last one expires.
+type OtpService struct {
+	client SmsClient
+	policy OtpPolicy
+}
+
+func NewOtpService(client SmsClient, policy OtpPolicy) *OtpService {
+	if policy.Length <= 0 {
+		policy.Length = defaultOtpLength
+	}
+	if policy.Alphabet == "" {
+		policy.Alphabet = defaultOtpAlphabet
+	}
+	if policy.TTL <= 0 {
+		policy.TTL = defaultOtpTTL
+	}
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = defaultOtpMaxAttempts
+	}
+	if policy.Store == nil {
+		policy.Store = NewMemoryOtpStore()
+	}
+
+	return &OtpService{
+		client: client,
+		policy: policy,
+	}
+}
+
+// Send generates a code and sends it to phoneNumber as param["code"], along
+// with the other params. The new code replaces the previous one and keeps its
+// failed attempts.
+func (s *OtpService) Send(ctx context.Context, phoneNumber string, param map[string]string) error {
+	code, err := GenerateOtp(s.policy.Length, s.policy.Alphabet)
+	if err != nil {
+		return err
+	}
+	salt := make([]byte, 16)
+	if _, err = rand.Read(salt); err != nil {
+		return err
+	}
+
+	key := s.key(phoneNumber)
+	now := time.Now()
+	sent := &OtpRecord{
+		Hash:      hashOtp(salt, code),
+		Salt:      salt,
+		ExpiresAt: now.Add(s.policy.TTL),
+	}
+	var previous *OtpRecord
+	err = s.policy.Store.Update(ctx, key, func(record *OtpRecord) (*OtpRecord, error) {
+		previous = record
+		if record != nil && now.Before(record.ExpiresAt) {
+			if record.Attempts >= s.policy.MaxAttempts {
+				return record, ErrOtpTooManyAttempts
+			}
+			sent.Attempts = record.Attempts
+		}
+		return sent, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	sendParam := map[string]string{}
+	for key, value := range param {
+		sendParam[key] = value
+	}
+	sendParam["code"] = code
+
+	_, err = SendMessageWithResult(ctx, s.client, sendParam, phoneNumber)
+	if err != nil {
+		// restore the previous code unless another one was sent meanwhile
+		_ = s.policy.Store.Update(ctx, key, func(record *OtpRecord) (*OtpRecord, error) {
+			if record == nil || !bytes.Equal(record.Hash, sent.Hash) {
+				return record, nil
+			}
+			if previous != nil && record.Attempts > previous.Attempts {
+				previous.Attempts = record.Attempts
+			}
+			return previous, nil
+		})
+		return err
+	}
+
+	return nil
+}
+
+// Verify checks the code sent to phoneNumber, which can only be used once.
+// The attempt is counted and the code consumed in a single store update, so
+// that concurrent guesses cannot exceed MaxAttempts.
+func (s *OtpService) Verify(ctx context.Context, phoneNumber string, code string) error {
+	now := time.Now()
+	return s.policy.Store.Update(ctx, s.key(phoneNumber), func(record *OtpRecord) (*OtpRecord, error) {
+		if record == nil {
+			return nil, ErrOtpNotFound
+		}
+		if !now.Before(record.ExpiresAt) {
+			return record, ErrOtpExpired
+		}
+		if record.Attempts >= s.policy.MaxAttempts {
+			return record, ErrOtpTooManyAttempts
+		}
+
+		record.Attempts++
+		if subtle.ConstantTimeCompare(hashOtp(record.Salt, code), record.Hash) != 1 {
+			return record, ErrOtpMismatch
+		}
+		return nil, nil
+	})
+}
+
+// key is phoneNumber in E.164 format, so that every format of a number shares
+// its code and attempts, or phoneNumber itself if it cannot be parsed.
+func (s *OtpService) key(phoneNumber string) string {
+	number, err := phone.Normalize(phoneNumber, s.policy.DefaultRegion)
+	if err != nil {
+		return strings.TrimSpace(phoneNumber)
+	}
+	return number
+}
+
+// GenerateOtp returns a cryptographically random code of length characters
+// of alphabet.
+func GenerateOtp(length int, alphabet string) (string, error) {
+	symbols := []rune(alphabet)
+	if len(symbols) == 0 {
+		return "", missingParameterError("alphabet")
+	}
+
+	max := big.NewInt(int64(len(symbols)))
+	code := make([]rune, length)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		code[i] = symbols[n.Int64()]
+	}
+
+	return string(code), nil
+}
+
+func hashOtp(salt []byte, code string) []byte {
+	h := sha256.New()
+	h.Write(salt)
+	h.Write([]byte(code))
+	return h.Sum(nil)
+}
+
+// MemoryOtpStore is an OtpStore for a single process.
+type MemoryOtpStore struct {
+	mu        sync.Mutex
+	records   map[string]*OtpRecord
+	lastSweep time.Time
+}
+
+func NewMemoryOtpStore() *MemoryOtpStore {
+	return &MemoryOtpStore{
+		records:   map[string]*OtpRecord{},
+		lastSweep: time.Now(),
+	}
+}
+
+func (s *MemoryOtpStore) Update(ctx context.Context, key string, update func(record *OtpRecord) (*OtpRecord, error)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(time.Now())
+
+	var current *OtpRecord
+	if record, ok := s.records[key]; ok {
+		copied := *record
+		current = &copied
+	}
+
+	updated, err := update(current)
+	if updated == nil {
+		delete(s.records, key)
+	} else {
+		copied := *updated
+		s.records[key] = &copied
+	}
+	return err
+}
+
+// sweep drops, once a minute, the expired records.
+func (s *MemoryOtpStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	s.lastSweep = now
+
+	for key, record := range s.records {
+		if !now.Before(record.ExpiresAt) {
+			delete(s.records, key)
+		}
+	}
+}
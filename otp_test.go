@@ -0,0 +1,152 @@
+// Copyright 2024 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_sms_sender
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// codeClient records the codes it sends, and fails the sends while err is set.
+type codeClient struct {
+	codes []string
+	err   error
+}
+
+func (c *codeClient) SendMessage(param map[string]string, targetPhoneNumber ...string) error {
+	if c.err != nil {
+		return c.err
+	}
+	c.codes = append(c.codes, param["code"])
+	return nil
+}
+
+// otpStep sends a code, or verifies the first or last code sent or a wrong
+// one, after waiting for the TTL if expire is set.
+type otpStep struct {
+	send        bool
+	sendErr     error
+	verify      string
+	phoneNumber string
+	expire      bool
+	wantErr     error
+}
+
+func TestOtpServiceLockout(t *testing.T) {
+	const number = "+8613800138000"
+	send := otpStep{send: true}
+	right := otpStep{verify: "last"}
+	wrong := otpStep{verify: "wrong", wantErr: ErrOtpMismatch}
+	locked := otpStep{verify: "last", wantErr: ErrOtpTooManyAttempts}
+	tests := []struct {
+		name  string
+		steps []otpStep
+	}{
+		{"no code sent", []otpStep{{verify: "wrong", wantErr: ErrOtpNotFound}}},
+		{"code is used once", []otpStep{send, right, {verify: "last", wantErr: ErrOtpNotFound}}},
+		{"wrong then right", []otpStep{send, wrong, wrong, right}},
+		{"locked after max attempts", []otpStep{send, wrong, wrong, wrong, locked, locked}},
+		{"resend keeps attempts", []otpStep{send, wrong, wrong, send, wrong, locked}},
+		{"resend refused while locked", []otpStep{send, wrong, wrong, wrong, {send: true, wantErr: ErrOtpTooManyAttempts}, locked}},
+		{"resend replaces the code", []otpStep{send, send, {verify: "first", wantErr: ErrOtpMismatch}, right}},
+		{"expired code", []otpStep{send, {expire: true, verify: "last", wantErr: ErrOtpExpired}}},
+		{"lock ends with the code", []otpStep{send, wrong, wrong, wrong, {expire: true, send: true}, right}},
+		{"failed resend keeps the code", []otpStep{send, wrong, {send: true, sendErr: errUnavailable, wantErr: ErrProviderUnavailable}, wrong, {verify: "first"}}},
+		{"national and formatted numbers share the code", []otpStep{
+			{send: true, phoneNumber: "138 0013 8000"},
+			{verify: "wrong", phoneNumber: "0086-138-0013-8000", wantErr: ErrOtpMismatch},
+			{verify: "last", phoneNumber: number},
+		}},
+		{"national and formatted numbers share the lock", []otpStep{
+			send,
+			{verify: "wrong", phoneNumber: "13800138000", wantErr: ErrOtpMismatch},
+			{verify: "wrong", phoneNumber: "8613800138000", wantErr: ErrOtpMismatch},
+			{verify: "wrong", phoneNumber: "+86 138 0013 8000", wantErr: ErrOtpMismatch},
+			{verify: "last", phoneNumber: "13800138000", wantErr: ErrOtpTooManyAttempts},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &codeClient{}
+			policy := OtpPolicy{TTL: 20 * time.Millisecond, MaxAttempts: 3, DefaultRegion: "CN"}
+			service := NewOtpService(client, policy)
+
+			for i, step := range tt.steps {
+				if step.expire {
+					time.Sleep(policy.TTL)
+				}
+				phoneNumber := step.phoneNumber
+				if phoneNumber == "" {
+					phoneNumber = number
+				}
+
+				var err error
+				if step.send {
+					client.err = step.sendErr
+					err = service.Send(context.Background(), phoneNumber, nil)
+				} else {
+					code := "wrong"
+					switch {
+					case len(client.codes) == 0:
+					case step.verify == "first":
+						code = client.codes[0]
+					case step.verify == "last":
+						code = client.codes[len(client.codes)-1]
+					}
+					err = service.Verify(context.Background(), phoneNumber, code)
+				}
+
+				if !errors.Is(err, step.wantErr) || (err != nil) != (step.wantErr != nil) {
+					t.Fatalf("step %d: error = %v, want %v", i+1, err, step.wantErr)
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateOtp(t *testing.T) {
+	tests := []struct {
+		length   int
+		alphabet string
+	}{
+		{6, "0123456789"},
+		{8, "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"},
+		{4, "验证码"},
+	}
+
+	for _, tt := range tests {
+		code, err := GenerateOtp(tt.length, tt.alphabet)
+		if err != nil {
+			t.Fatalf("GenerateOtp(%d, %q) error = %v", tt.length, tt.alphabet, err)
+		}
+		runes := []rune(code)
+		if len(runes) != tt.length {
+			t.Errorf("GenerateOtp(%d, %q) = %q, want %d characters", tt.length, tt.alphabet, code, tt.length)
+		}
+		for _, r := range runes {
+			if !strings.ContainsRune(tt.alphabet, r) {
+				t.Errorf("GenerateOtp(%d, %q) = %q, %q is not in the alphabet", tt.length, tt.alphabet, code, r)
+			}
+		}
+	}
+
+	if _, err := GenerateOtp(6, ""); !errors.Is(err, ErrMissingParameter) {
+		t.Errorf("GenerateOtp with an empty alphabet error = %v, want ErrMissingParameter", err)
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright 2024 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_sms_sender
+
+import (
+	"github.com/casdoor/go-sms-sender/phone"
+)
+
+// phoneFormat is how a provider expects target phone numbers. defaultRegion
+// is the region of the numbers written without country code, and countryCode
+// restricts the provider to a single country.
+type phoneFormat struct {
+	format        phone.Format
+	defaultRegion string
+	countryCode   string
+}
+
+var phoneFormats = map[string]phoneFormat{
+	Msg91:   {format: phone.Digits},
+	GCCPAY:  {format: phone.Digits},
+	Infobip: {format: phone.Digits, defaultRegion: "TW"},
+	SmsBao:  {format: phone.National, defaultRegion: "CN", countryCode: "86"},
+}
+
+// FormatPhoneNumber validates phoneNumber and returns it the way provider
+// expects it, in E.164 format for the providers without a specific one.
+// Invalid numbers and numbers of countries the provider does not serve return
+// an *SmsError of kind ErrInvalidNumber.
+func FormatPhoneNumber(provider string, phoneNumber string) (string, error) {
+	format := phoneFormats[provider]
+	number, err := phone.Parse(phoneNumber, format.defaultRegion)
+	if err != nil {
+		return "", &SmsError{Provider: provider, Message: err.Error(), Kind: ErrInvalidNumber}
+	}
+
+	if format.countryCode != "" && number.CountryCode != format.countryCode {
+		return "", &SmsError{Provider: provider, Message: "unsupported country code +" + number.CountryCode, Kind: ErrInvalidNumber}
+	}
+
+	return number.Format(format.format), nil
+}
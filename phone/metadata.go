@@ -0,0 +1,170 @@
+// Copyright 2024 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package phone
+
+// country is the metadata of a region: its calling code, the range of lengths
+// of its national significant numbers and the trunk prefix dialed before them
+// inside the country.
+type country struct {
+	region      string
+	code        string
+	minLength   int
+	maxLength   int
+	trunkPrefix string
+}
+
+func (c *country) valid(national string) bool {
+	if len(national) < c.minLength || len(national) > c.maxLength {
+		return false
+	}
+	// Only Italian numbers keep a leading zero in international format.
+	return national[0] != '0' || c.region == "IT"
+}
+
+// countries lists the main region of a calling code first.
+var countries = []country{
+	{"US", "1", 10, 10, "1"},
+	{"CA", "1", 10, 10, "1"},
+	{"RU", "7", 10, 10, "8"},
+	{"KZ", "7", 10, 10, "8"},
+	{"EG", "20", 8, 10, "0"},
+	{"ZA", "27", 9, 9, "0"},
+	{"GR", "30", 10, 10, ""},
+	{"NL", "31", 9, 9, "0"},
+	{"BE", "32", 8, 9, "0"},
+	{"FR", "33", 9, 9, "0"},
+	{"ES", "34", 9, 9, ""},
+	{"HU", "36", 8, 9, "06"},
+	{"IT", "39", 6, 11, ""},
+	{"RO", "40", 9, 9, "0"},
+	{"CH", "41", 9, 9, "0"},
+	{"AT", "43", 4, 13, "0"},
+	{"GB", "44", 7, 10, "0"},
+	{"DK", "45", 8, 8, ""},
+	{"SE", "46", 7, 13, "0"},
+	{"NO", "47", 5, 8, ""},
+	{"PL", "48", 9, 9, ""},
+	{"DE", "49", 5, 15, "0"},
+	{"PE", "51", 8, 9, "0"},
+	{"MX", "52", 10, 10, ""},
+	{"CU", "53", 6, 8, "0"},
+	{"AR", "54", 10, 11, "0"},
+	{"BR", "55", 10, 11, "0"},
+	{"CL", "56", 9, 9, ""},
+	{"CO", "57", 8, 10, "0"},
+	{"VE", "58", 10, 10, "0"},
+	{"MY", "60", 7, 10, "0"},
+	{"AU", "61", 9, 9, "0"},
+	{"ID", "62", 7, 12, "0"},
+	{"PH", "63", 8, 10, "0"},
+	{"NZ", "64", 8, 10, "0"},
+	{"SG", "65", 8, 8, ""},
+	{"TH", "66", 8, 9, "0"},
+	{"JP", "81", 9, 10, "0"},
+	{"KR", "82", 8, 10, "0"},
+	{"VN", "84", 9, 10, "0"},
+	{"CN", "86", 9, 11, "0"},
+	{"TR", "90", 10, 10, "0"},
+	{"IN", "91", 10, 10, "0"},
+	{"PK", "92", 9, 10, "0"},
+	{"AF", "93", 9, 9, "0"},
+	{"LK", "94", 9, 9, "0"},
+	{"MM", "95", 7, 10, "0"},
+	{"IR", "98", 10, 10, "0"},
+	{"MA", "212", 9, 9, "0"},
+	{"DZ", "213", 8, 9, "0"},
+	{"TN", "216", 8, 8, ""},
+	{"LY", "218", 9, 9, "0"},
+	{"SN", "221", 9, 9, ""},
+	{"GH", "233", 9, 9, "0"},
+	{"NG", "234", 8, 10, "0"},
+	{"KE", "254", 9, 9, "0"},
+	{"TZ", "255", 9, 9, "0"},
+	{"UG", "256", 9, 9, "0"},
+	{"ZM", "260", 9, 9, "0"},
+	{"ZW", "263", 9, 9, "0"},
+	{"PT", "351", 9, 9, ""},
+	{"LU", "352", 4, 11, ""},
+	{"IE", "353", 7, 9, "0"},
+	{"IS", "354", 7, 7, ""},
+	{"FI", "358", 5, 12, "0"},
+	{"LT", "370", 8, 8, "8"},
+	{"LV", "371", 8, 8, ""},
+	{"EE", "372", 7, 8, ""},
+	{"MD", "373", 8, 8, "0"},
+	{"AM", "374", 8, 8, "0"},
+	{"BY", "375", 9, 9, "8"},
+	{"UA", "380", 9, 9, "0"},
+	{"RS", "381", 8, 9, "0"},
+	{"HR", "385", 8, 9, "0"},
+	{"SI", "386", 8, 8, "0"},
+	{"CZ", "420", 9, 9, ""},
+	{"SK", "421", 9, 9, "0"},
+	{"HK", "852", 8, 8, ""},
+	{"MO", "853", 8, 8, ""},
+	{"KH", "855", 8, 9, "0"},
+	{"LA", "856", 8, 10, "0"},
+	{"BD", "880", 10, 10, "0"},
+	{"TW", "886", 8, 9, "0"},
+	{"MV", "960", 7, 7, ""},
+	{"LB", "961", 7, 8, "0"},
+	{"JO", "962", 8, 9, "0"},
+	{"SY", "963", 9, 9, "0"},
+	{"IQ", "964", 10, 10, "0"},
+	{"KW", "965", 8, 8, ""},
+	{"SA", "966", 9, 9, "0"},
+	{"YE", "967", 9, 9, "0"},
+	{"OM", "968", 8, 8, ""},
+	{"PS", "970", 9, 9, "0"},
+	{"AE", "971", 8, 9, "0"},
+	{"IL", "972", 8, 9, "0"},
+	{"BH", "973", 8, 8, ""},
+	{"QA", "974", 8, 8, ""},
+	{"BT", "975", 8, 8, ""},
+	{"MN", "976", 8, 8, "0"},
+	{"NP", "977", 8, 10, "0"},
+	{"TJ", "992", 9, 9, ""},
+	{"TM", "993", 8, 8, "8"},
+	{"AZ", "994", 9, 9, "0"},
+	{"GE", "995", 9, 9, "0"},
+	{"KG", "996", 9, 9, "0"},
+	{"UZ", "998", 9, 9, ""},
+}
+
+// twoDigitCodes are the two-digit country calling codes.
+var twoDigitCodes = map[string]bool{}
+
+var (
+	regions      = map[string]*country{}
+	countryCodes = map[string]*country{}
+)
+
+func init() {
+	for _, code := range []string{
+		"20", "27", "30", "31", "32", "33", "34", "36", "39", "40", "41", "43", "44", "45", "46", "47", "48", "49",
+		"51", "52", "53", "54", "55", "56", "57", "58", "60", "61", "62", "63", "64", "65", "66",
+		"81", "82", "84", "86", "90", "91", "92", "93", "94", "95", "98",
+	} {
+		twoDigitCodes[code] = true
+	}
+
+	for i := range countries {
+		c := &countries[i]
+		regions[c.region] = c
+		if _, ok := countryCodes[c.code]; !ok {
+			countryCodes[c.code] = c
+		}
+	}
+}
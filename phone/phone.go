@@ -0,0 +1,164 @@
+// Copyright 2024 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package phone parses, validates and formats E.164 phone numbers.
+package phone
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrInvalidNumber = errors.New("invalid phone number")
+
+type Format int
+
+const (
+	// E164 is the international format with a plus sign, e.g. +8613800138000.
+	E164 Format = iota
+	// Digits is E164 without the plus sign, e.g. 8613800138000.
+	Digits
+	// National is the national significant number, e.g. 13800138000.
+	National
+)
+
+// Number is a parsed phone number.
+type Number struct {
+	// CountryCode is the country calling code, e.g. "86".
+	CountryCode string
+	// Region is the ISO 3166-1 region of the country calling code, e.g. "CN".
+	// It is the main region for codes shared by several, and empty for codes
+	// without metadata.
+	Region string
+	// NationalNumber is the national significant number, without trunk prefix.
+	NationalNumber string
+}
+
+// Parse parses number, which may contain spaces, dashes, dots and
+// parentheses. A number starting with "+" or "00" is international. Other
+// numbers are national numbers of defaultRegion, or if they are not valid as
+// such or defaultRegion is empty, digits starting with the country calling code.
+func Parse(number string, defaultRegion string) (*Number, error) {
+	digits, international, err := clean(number)
+	if err != nil {
+		return nil, err
+	}
+
+	if !international && defaultRegion != "" {
+		c, ok := regions[strings.ToUpper(defaultRegion)]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown region %s", ErrInvalidNumber, defaultRegion)
+		}
+
+		national := digits
+		if c.trunkPrefix != "" && strings.HasPrefix(national, c.trunkPrefix) {
+			national = national[len(c.trunkPrefix):]
+		}
+		if c.valid(national) {
+			return &Number{CountryCode: c.code, Region: c.region, NationalNumber: national}, nil
+		}
+	}
+
+	return parseDigits(number, digits)
+}
+
+// Normalize returns number in E.164 format, see Parse.
+func Normalize(number string, defaultRegion string) (string, error) {
+	n, err := Parse(number, defaultRegion)
+	if err != nil {
+		return "", err
+	}
+	return n.E164(), nil
+}
+
+// Validate reports whether number is a valid phone number, see Parse.
+func Validate(number string, defaultRegion string) error {
+	_, err := Parse(number, defaultRegion)
+	return err
+}
+
+func (n *Number) E164() string {
+	return "+" + n.CountryCode + n.NationalNumber
+}
+
+func (n *Number) Format(format Format) string {
+	switch format {
+	case Digits:
+		return n.CountryCode + n.NationalNumber
+	case National:
+		return n.NationalNumber
+	default:
+		return n.E164()
+	}
+}
+
+func (n *Number) String() string {
+	return n.E164()
+}
+
+func clean(number string) (string, bool, error) {
+	var b strings.Builder
+	international := false
+	for i, r := range strings.TrimSpace(number) {
+		switch {
+		case r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case r == '+' && i == 0:
+			international = true
+		case r == ' ' || r == '-' || r == '.' || r == '(' || r == ')':
+		default:
+			return "", false, fmt.Errorf("%w: %q", ErrInvalidNumber, number)
+		}
+	}
+
+	digits := b.String()
+	if !international && strings.HasPrefix(digits, "00") {
+		digits = digits[2:]
+		international = true
+	}
+	if digits == "" {
+		return "", false, fmt.Errorf("%w: %q", ErrInvalidNumber, number)
+	}
+
+	return digits, international, nil
+}
+
+// parseDigits splits digits starting with a country calling code. Calling
+// codes are prefix-free: 1 and 7 have one digit, the ones in twoDigitCodes
+// two and all the others three.
+func parseDigits(number string, digits string) (*Number, error) {
+	codeLength := 3
+	switch {
+	case digits[0] == '1' || digits[0] == '7':
+		codeLength = 1
+	case len(digits) >= 2 && twoDigitCodes[digits[:2]]:
+		codeLength = 2
+	}
+
+	if len(digits) <= codeLength || len(digits) > 15 || digits[0] == '0' {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidNumber, number)
+	}
+
+	code, national := digits[:codeLength], digits[codeLength:]
+	c, ok := countryCodes[code]
+	if !ok {
+		c = &country{code: code, minLength: 4, maxLength: 15 - codeLength}
+	}
+	if !c.valid(national) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidNumber, number)
+	}
+
+	return &Number{CountryCode: code, Region: c.region, NationalNumber: national}, nil
+}
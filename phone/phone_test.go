@@ -0,0 +1,120 @@
+// Copyright 2024 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package phone
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name          string
+		number        string
+		defaultRegion string
+		want          Number
+	}{
+		{"international", "+86 138-0013-8000", "", Number{"86", "CN", "13800138000"}},
+		{"00 prefix", "008613800138000", "", Number{"86", "CN", "13800138000"}},
+		{"national", "13800138000", "CN", Number{"86", "CN", "13800138000"}},
+		{"digits with calling code", "8613800138000", "", Number{"86", "CN", "13800138000"}},
+		{"digits not national", "8613800138000", "CN", Number{"86", "CN", "13800138000"}},
+		{"international ignores region", "+12065550100", "CN", Number{"1", "US", "2065550100"}},
+		{"us punctuation", "(206) 555.0100", "us", Number{"1", "US", "2065550100"}},
+		{"us trunk prefix", "1 206 555 0100", "US", Number{"1", "US", "2065550100"}},
+		{"turkish trunk prefix", "0532 123 45 67", "TR", Number{"90", "TR", "5321234567"}},
+		{"british trunk prefix", "07911 123456", "GB", Number{"44", "GB", "7911123456"}},
+		{"italian leading zero", "+39 06 1234 5678", "", Number{"39", "IT", "0612345678"}},
+		{"unknown calling code", "+999 1234567", "", Number{"999", "", "1234567"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.number, tt.defaultRegion)
+			if err != nil {
+				t.Fatalf("Parse(%q, %q) error = %v", tt.number, tt.defaultRegion, err)
+			}
+			if *got != tt.want {
+				t.Errorf("Parse(%q, %q) = %+v, want %+v", tt.number, tt.defaultRegion, *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := []struct {
+		name          string
+		number        string
+		defaultRegion string
+	}{
+		{"empty", "", ""},
+		{"letters", "138abc00138000", "CN"},
+		{"plus inside", "86+13800138000", ""},
+		{"leading zero", "+0086 13800138000", ""},
+		{"too short", "+86 138", ""},
+		{"too long", "+86 1380013800012345", ""},
+		{"national without region", "0532 123 45 67", ""},
+		{"unknown region", "13800138000", "XX"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, err := Parse(tt.number, tt.defaultRegion); !errors.Is(err, ErrInvalidNumber) {
+				t.Errorf("Parse(%q, %q) = %v, %v, want ErrInvalidNumber", tt.number, tt.defaultRegion, got, err)
+			}
+		})
+	}
+}
+
+func TestFormat(t *testing.T) {
+	number := &Number{CountryCode: "86", Region: "CN", NationalNumber: "13800138000"}
+	tests := []struct {
+		format Format
+		want   string
+	}{
+		{E164, "+8613800138000"},
+		{Digits, "8613800138000"},
+		{National, "13800138000"},
+	}
+
+	for _, tt := range tests {
+		if got := number.Format(tt.format); got != tt.want {
+			t.Errorf("Format(%d) = %q, want %q", tt.format, got, tt.want)
+		}
+	}
+	if got := number.String(); got != "+8613800138000" {
+		t.Errorf("String() = %q, want %q", got, "+8613800138000")
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		number        string
+		defaultRegion string
+		want          string
+	}{
+		{"+86 138 0013 8000", "", "+8613800138000"},
+		{"138 0013 8000", "CN", "+8613800138000"},
+		{"0049 30 123456", "", "+4930123456"},
+		{"030 123456", "DE", "+4930123456"},
+	}
+
+	for _, tt := range tests {
+		got, err := Normalize(tt.number, tt.defaultRegion)
+		if err != nil || got != tt.want {
+			t.Errorf("Normalize(%q, %q) = %q, %v, want %q", tt.number, tt.defaultRegion, got, err, tt.want)
+		}
+	}
+}
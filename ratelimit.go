@@ -0,0 +1,334 @@
+// Copyright 2024 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_sms_sender
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/casdoor/go-sms-sender/phone"
+)
+
+// RateLimit allows Limit sends per Period, e.g. {Limit: 10, Period: 24 * time.Hour}.
+// It is enforced as a token bucket holding up to Limit tokens.
+type RateLimit struct {
+	Limit  int
+	Period time.Duration
+}
+
+// RateLimitStore keeps the token buckets of a RateLimitClient, so that
+// several processes can share them. Take removes one token from the bucket of
+// key for every limit, only if all of them have one, and otherwise returns
+// how long until they all do. Refund gives back a token taken for a send that
+// failed.
+type RateLimitStore interface {
+	Take(ctx context.Context, key string, limits []RateLimit) (time.Duration, error)
+	Refund(ctx context.Context, key string, limits []RateLimit) error
+}
+
+// RateLimitError is returned for a send denied by a RateLimitClient, Key is
+// the limited account or destination number.
+type RateLimitError struct {
+	Key        string
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("rate limit exceeded for %s, retry after %s", e.Key, e.RetryAfter)
+}
+
+func (e *RateLimitError) Unwrap() error {
+	return ErrRateLimited
+}
+
+// RateLimitPolicy configures a RateLimitClient. AccountLimits apply to every
+// message sent through the clients sharing Account and Store, one per
+// recipient, DestinationLimits to every target phone number. Store defaults
+// to a new MemoryRateLimitStore. DefaultRegion is the region of national
+// phone numbers, which are limited by their E.164 format.
+type RateLimitPolicy struct {
+	Account           string
+	AccountLimits     []RateLimit
+	DestinationLimits []RateLimit
+	Store             RateLimitStore
+	DefaultRegion     string
+}
+
+// RateLimitClient enforces per-account and per-destination limits before
+// sending. Limited destinations are reported as rejected and the message is
+// sent to the others, the error is then a *RateLimitError. The tokens of
+// recipients the send did not reach are refunded.
+type RateLimitClient struct {
+	client SmsClient
+	policy RateLimitPolicy
+}
+
+var _ ResultSmsClient = &RateLimitClient{}
+
+func NewRateLimitClient(client SmsClient, policy RateLimitPolicy) *RateLimitClient {
+	if policy.Store == nil {
+		policy.Store = NewMemoryRateLimitStore()
+	}
+
+	return &RateLimitClient{
+		client: client,
+		policy: policy,
+	}
+}
+
+func (c *RateLimitClient) SendMessage(param map[string]string, targetPhoneNumber ...string) error {
+	return c.SendMessageContext(context.Background(), param, targetPhoneNumber...)
+}
+
+func (c *RateLimitClient) SendMessageContext(ctx context.Context, param map[string]string, targetPhoneNumber ...string) error {
+	_, err := c.SendMessageResult(ctx, param, targetPhoneNumber...)
+	return err
+}
+
+func (c *RateLimitClient) SendMessageResult(ctx context.Context, param map[string]string, targetPhoneNumber ...string) (*SendResult, error) {
+	allowed := []string{}
+	var limited []RecipientResult
+	var limitErr *RateLimitError
+	for _, phoneNumber := range targetPhoneNumber {
+		recipientErr, err := c.take(ctx, phoneNumber)
+		if err != nil {
+			c.refund(ctx, allowed)
+			return nil, err
+		}
+		if recipientErr == nil {
+			allowed = append(allowed, phoneNumber)
+			continue
+		}
+
+		if limitErr == nil || recipientErr.RetryAfter < limitErr.RetryAfter {
+			limitErr = recipientErr
+		}
+		limited = append(limited, RecipientResult{
+			PhoneNumber: phoneNumber,
+			Status:      SendStatusRejected,
+			Message:     recipientErr.Error(),
+		})
+	}
+
+	result := &SendResult{Recipients: []RecipientResult{}}
+	if len(allowed) > 0 {
+		sendResult, err := SendMessageWithResult(ctx, c.client, param, allowed...)
+		if sendResult != nil {
+			result = sendResult
+		}
+		if err != nil {
+			// only the recipients accepted before the failure are charged
+			notSent := allowed
+			for _, recipient := range result.Recipients {
+				if recipient.Status == SendStatusAccepted {
+					notSent = withoutRecipient(notSent, recipient.PhoneNumber)
+				}
+			}
+			c.refund(ctx, notSent)
+
+			result.Recipients = append(result.Recipients, limited...)
+			return result, err
+		}
+	}
+
+	if limitErr == nil {
+		return result, nil
+	}
+
+	result.Recipients = append(result.Recipients, limited...)
+	return result, limitErr
+}
+
+// QueryBalance queries the balance of the wrapped client without taking tokens.
+func (c *RateLimitClient) QueryBalance(ctx context.Context) (*Balance, error) {
+	return QueryBalance(ctx, c.client)
+}
+
+// QueryStatus queries the delivery status through the wrapped client, which
+// is not rate limited.
+func (c *RateLimitClient) QueryStatus(ctx context.Context, query StatusQuery) (*DeliveryReport, error) {
+	return QueryStatus(ctx, c.client, query)
+}
+
+// take takes the destination and account tokens of a recipient, or returns
+// the limit denying it without taking any.
+func (c *RateLimitClient) take(ctx context.Context, phoneNumber string) (*RateLimitError, error) {
+	if len(c.policy.DestinationLimits) > 0 {
+		wait, err := c.policy.Store.Take(ctx, c.destinationKey(phoneNumber), c.policy.DestinationLimits)
+		if err != nil {
+			return nil, err
+		}
+		if wait > 0 {
+			return &RateLimitError{Key: phoneNumber, RetryAfter: wait}, nil
+		}
+	}
+
+	if len(c.policy.AccountLimits) > 0 {
+		wait, err := c.policy.Store.Take(ctx, "account:"+c.policy.Account, c.policy.AccountLimits)
+		if err == nil && wait == 0 {
+			return nil, nil
+		}
+
+		if len(c.policy.DestinationLimits) > 0 {
+			_ = c.policy.Store.Refund(ctx, c.destinationKey(phoneNumber), c.policy.DestinationLimits)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &RateLimitError{Key: c.policy.Account, RetryAfter: wait}, nil
+	}
+
+	return nil, nil
+}
+
+// destinationKey is the bucket key of phoneNumber in E.164 format, so that
+// every format of a number shares its limits, or of phoneNumber itself if it
+// cannot be parsed.
+func (c *RateLimitClient) destinationKey(phoneNumber string) string {
+	number, err := phone.Normalize(phoneNumber, c.policy.DefaultRegion)
+	if err != nil {
+		return "destination:" + strings.TrimSpace(phoneNumber)
+	}
+	return "destination:" + number
+}
+
+// refund gives back the tokens taken for phoneNumbers. Errors are ignored,
+// a lost refund only makes the limit stricter.
+func (c *RateLimitClient) refund(ctx context.Context, phoneNumbers []string) {
+	for _, phoneNumber := range phoneNumbers {
+		if len(c.policy.DestinationLimits) > 0 {
+			_ = c.policy.Store.Refund(ctx, c.destinationKey(phoneNumber), c.policy.DestinationLimits)
+		}
+		if len(c.policy.AccountLimits) > 0 {
+			_ = c.policy.Store.Refund(ctx, "account:"+c.policy.Account, c.policy.AccountLimits)
+		}
+	}
+}
+
+// MemoryRateLimitStore is a RateLimitStore for a single process.
+type MemoryRateLimitStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+type tokenBucket struct {
+	limit   RateLimit
+	tokens  float64
+	updated time.Time
+}
+
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{
+		buckets:   map[string]*tokenBucket{},
+		lastSweep: time.Now(),
+	}
+}
+
+func (s *MemoryRateLimitStore) Take(ctx context.Context, key string, limits []RateLimit) (time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.sweep(now)
+
+	buckets := make([]*tokenBucket, len(limits))
+	var wait time.Duration
+	for i, limit := range limits {
+		if limit.Limit <= 0 || limit.Period <= 0 {
+			continue
+		}
+
+		bucketKey := rateLimitBucketKey(key, limit)
+		bucket, ok := s.buckets[bucketKey]
+		if !ok {
+			bucket = &tokenBucket{limit: limit, tokens: float64(limit.Limit), updated: now}
+			s.buckets[bucketKey] = bucket
+		}
+		bucket.refill(now)
+		buckets[i] = bucket
+
+		if bucket.tokens < 1 {
+			rate := float64(limit.Limit) / float64(limit.Period)
+			if bucketWait := time.Duration((1 - bucket.tokens) / rate); bucketWait > wait {
+				wait = bucketWait
+			}
+		}
+	}
+
+	if wait > 0 {
+		return wait, nil
+	}
+
+	for _, bucket := range buckets {
+		if bucket != nil {
+			bucket.tokens--
+		}
+	}
+	return 0, nil
+}
+
+func (s *MemoryRateLimitStore) Refund(ctx context.Context, key string, limits []RateLimit) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for _, limit := range limits {
+		bucket, ok := s.buckets[rateLimitBucketKey(key, limit)]
+		if !ok {
+			continue
+		}
+
+		bucket.refill(now)
+		bucket.tokens++
+		if bucket.tokens > float64(limit.Limit) {
+			bucket.tokens = float64(limit.Limit)
+		}
+	}
+	return nil
+}
+
+func rateLimitBucketKey(key string, limit RateLimit) string {
+	return fmt.Sprintf("%s|%d/%s", key, limit.Limit, limit.Period)
+}
+
+func (b *tokenBucket) refill(now time.Time) {
+	elapsed := now.Sub(b.updated)
+	b.updated = now
+
+	b.tokens += float64(b.limit.Limit) * float64(elapsed) / float64(b.limit.Period)
+	if b.tokens > float64(b.limit.Limit) {
+		b.tokens = float64(b.limit.Limit)
+	}
+}
+
+// sweep drops, once a minute, the buckets that are full again and so behave
+// as new ones, so that the buckets of destination numbers do not pile up.
+func (s *MemoryRateLimitStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	s.lastSweep = now
+
+	for key, bucket := range s.buckets {
+		bucket.refill(now)
+		if bucket.tokens >= float64(bucket.limit.Limit) {
+			delete(s.buckets, key)
+		}
+	}
+}
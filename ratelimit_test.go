@@ -0,0 +1,231 @@
+// Copyright 2024 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_sms_sender
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMemoryRateLimitStoreTake(t *testing.T) {
+	tests := []struct {
+		name   string
+		limits []RateLimit
+		takes  int
+		// allowed is the number of takes expected to succeed, the others must wait
+		allowed int
+		minWait time.Duration
+		maxWait time.Duration
+	}{
+		{"within limit", []RateLimit{{3, time.Hour}}, 3, 3, 0, 0},
+		{"over limit", []RateLimit{{3, time.Hour}}, 5, 3, 19 * time.Minute, 20 * time.Minute},
+		{"strictest limit", []RateLimit{{5, time.Hour}, {2, time.Hour}}, 3, 2, 29 * time.Minute, 30 * time.Minute},
+		{"longest wait", []RateLimit{{2, time.Minute}, {2, 24 * time.Hour}}, 3, 2, 11 * time.Hour, 12 * time.Hour},
+		{"zero limit ignored", []RateLimit{{0, time.Hour}, {1, 0}}, 10, 10, 0, 0},
+		{"no limits", nil, 10, 10, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewMemoryRateLimitStore()
+			allowed := 0
+			var wait time.Duration
+			for i := 0; i < tt.takes; i++ {
+				w, err := store.Take(context.Background(), "key", tt.limits)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if w == 0 {
+					allowed++
+				} else {
+					wait = w
+				}
+			}
+
+			if allowed != tt.allowed {
+				t.Errorf("allowed %d takes, want %d", allowed, tt.allowed)
+			}
+			if wait < tt.minWait || wait > tt.maxWait {
+				t.Errorf("wait = %s, want between %s and %s", wait, tt.minWait, tt.maxWait)
+			}
+		})
+	}
+}
+
+func TestMemoryRateLimitStoreDeniedTakeKeepsTokens(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryRateLimitStore()
+	limits := []RateLimit{{3, time.Hour}, {1, time.Hour}}
+
+	if wait, _ := store.Take(ctx, "key", limits); wait != 0 {
+		t.Fatalf("first take waits %s", wait)
+	}
+	if wait, _ := store.Take(ctx, "key", limits); wait == 0 {
+		t.Fatal("second take is allowed")
+	}
+
+	// the denied take left the two other tokens of the first limit
+	first := []RateLimit{{3, time.Hour}}
+	for i := 0; i < 2; i++ {
+		if wait, _ := store.Take(ctx, "key", first); wait != 0 {
+			t.Fatalf("take %d of the first limit waits %s", i+1, wait)
+		}
+	}
+	if wait, _ := store.Take(ctx, "key", first); wait == 0 {
+		t.Fatal("take of an empty bucket is allowed")
+	}
+
+	if err := store.Refund(ctx, "key", first); err != nil {
+		t.Fatal(err)
+	}
+	if wait, _ := store.Take(ctx, "key", first); wait != 0 {
+		t.Errorf("take after refund waits %s", wait)
+	}
+}
+
+func TestMemoryRateLimitStoreRefill(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryRateLimitStore()
+	limits := []RateLimit{{1, 20 * time.Millisecond}}
+
+	if wait, _ := store.Take(ctx, "key", limits); wait != 0 {
+		t.Fatalf("first take waits %s", wait)
+	}
+	wait, _ := store.Take(ctx, "key", limits)
+	if wait == 0 || wait > 20*time.Millisecond {
+		t.Fatalf("second take waits %s, want up to 20ms", wait)
+	}
+
+	time.Sleep(wait)
+	if wait, _ = store.Take(ctx, "key", limits); wait != 0 {
+		t.Errorf("take after refill waits %s", wait)
+	}
+}
+
+func TestRateLimitClient(t *testing.T) {
+	a, b, c := "+8613800138000", "+8613900139000", "+8613700137000"
+	tests := []struct {
+		name           string
+		policy         RateLimitPolicy
+		replies        []fakeReply
+		sends          [][]string
+		wantCalls      [][]string
+		wantRecipients []string
+		wantErr        error
+	}{
+		{
+			name:           "account limit counts recipients",
+			policy:         RateLimitPolicy{AccountLimits: []RateLimit{{2, time.Hour}}},
+			sends:          [][]string{{a, b, c}},
+			wantCalls:      [][]string{{a, b}},
+			wantRecipients: []string{a + "  accepted", b + "  accepted", c + "  rejected"},
+			wantErr:        ErrRateLimited,
+		},
+		{
+			name:           "destination limit",
+			policy:         RateLimitPolicy{DestinationLimits: []RateLimit{{1, time.Hour}}},
+			sends:          [][]string{{a}, {a, b}},
+			wantCalls:      [][]string{{a}, {b}},
+			wantRecipients: []string{b + "  accepted", a + "  rejected"},
+			wantErr:        ErrRateLimited,
+		},
+		{
+			name:           "limited destination does not charge the account",
+			policy:         RateLimitPolicy{AccountLimits: []RateLimit{{2, time.Hour}}, DestinationLimits: []RateLimit{{1, time.Hour}}},
+			sends:          [][]string{{a}, {a}, {b}},
+			wantCalls:      [][]string{{a}, {b}},
+			wantRecipients: []string{b + "  accepted"},
+		},
+		{
+			name:           "failed recipients are refunded",
+			policy:         RateLimitPolicy{AccountLimits: []RateLimit{{2, time.Hour}}, DestinationLimits: []RateLimit{{1, time.Hour}}},
+			replies:        []fakeReply{{rejected: []string{b}, err: errUnavailable}},
+			sends:          [][]string{{a, b}, {b}},
+			wantCalls:      [][]string{{a, b}, {b}},
+			wantRecipients: []string{b + "  accepted"},
+		},
+		{
+			name:           "send without result is refunded",
+			policy:         RateLimitPolicy{AccountLimits: []RateLimit{{2, time.Hour}}},
+			replies:        []fakeReply{{noResult: true, err: errUnavailable}},
+			sends:          [][]string{{a, b}, {a, b}},
+			wantCalls:      [][]string{{a, b}, {a, b}},
+			wantRecipients: []string{a + "  accepted", b + "  accepted"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakeClient{replies: tt.replies}
+			limiter := NewRateLimitClient(client, tt.policy)
+
+			var result *SendResult
+			var err error
+			for _, phoneNumbers := range tt.sends {
+				result, err = limiter.SendMessageResult(context.Background(), nil, phoneNumbers...)
+			}
+
+			if !errors.Is(err, tt.wantErr) || (err != nil) != (tt.wantErr != nil) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(client.calls, tt.wantCalls) {
+				t.Errorf("calls = %v, want %v", client.calls, tt.wantCalls)
+			}
+			if got := recipientSummary(result); !reflect.DeepEqual(got, tt.wantRecipients) {
+				t.Errorf("recipients = %v, want %v", got, tt.wantRecipients)
+			}
+		})
+	}
+}
+
+func TestRateLimitClientNumberFormats(t *testing.T) {
+	tests := []struct {
+		name          string
+		defaultRegion string
+		phoneNumbers  []string
+		wantAllowed   []string
+	}{
+		{"international formats", "", []string{"+8613800138000", "8613800138000", "+86 138 0013 8000", "008613800138000"}, []string{"+8613800138000"}},
+		{"national format", "CN", []string{"138-0013-8000", "+8613800138000"}, []string{"138-0013-8000"}},
+		{"national without region", "", []string{"05321234567", "+905321234567", " 05321234567"}, []string{"05321234567", "+905321234567"}},
+		{"different numbers", "", []string{"+8613800138000", "+8613800138001"}, []string{"+8613800138000", "+8613800138001"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakeClient{}
+			limiter := NewRateLimitClient(client, RateLimitPolicy{
+				DestinationLimits: []RateLimit{{1, time.Hour}},
+				DefaultRegion:     tt.defaultRegion,
+			})
+
+			allowed := []string{}
+			for _, phoneNumber := range tt.phoneNumbers {
+				_, err := limiter.SendMessageResult(context.Background(), nil, phoneNumber)
+				if err == nil {
+					allowed = append(allowed, phoneNumber)
+				} else if !errors.Is(err, ErrRateLimited) {
+					t.Fatalf("send to %s error = %v", phoneNumber, err)
+				}
+			}
+			if !reflect.DeepEqual(allowed, tt.wantAllowed) {
+				t.Errorf("allowed %v, want %v", allowed, tt.wantAllowed)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright 2024 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_sms_sender
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// ProviderFactory creates a client from a validated Config.
+type ProviderFactory func(config *Config) (SmsClient, error)
+
+// ProviderField describes one configuration value of a provider. Name is the
+// Config field it is read from, see Config.Get. Fields with Other set are
+// passed to NewSmsClient in the `other` arguments, in the order listed.
+type ProviderField struct {
+	Name     string
+	Label    string
+	Required bool
+	Other    bool
+}
+
+// ProviderInfo describes a registered provider.
+type ProviderInfo struct {
+	Name   string
+	Fields []ProviderField
+}
+
+type provider struct {
+	info    ProviderInfo
+	factory ProviderFactory
+}
+
+var (
+	providersMu sync.RWMutex
+	providers   = map[string]*provider{}
+)
+
+// Register makes a provider available to NewSmsClient under name.
+// It panics if name is empty, factory is nil or name is already registered.
+func Register(name string, factory ProviderFactory, fields ...ProviderField) {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+
+	if name == "" {
+		panic("go_sms_sender: Register provider name is empty")
+	}
+	if factory == nil {
+		panic("go_sms_sender: Register factory is nil for provider " + name)
+	}
+	if _, ok := providers[name]; ok {
+		panic("go_sms_sender: Register called twice for provider " + name)
+	}
+
+	providers[name] = &provider{
+		info: ProviderInfo{
+			Name:   name,
+			Fields: fields,
+		},
+		factory: factory,
+	}
+}
+
+// ListProviders returns the registered providers sorted by name.
+func ListProviders() []ProviderInfo {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+
+	infos := make([]ProviderInfo, 0, len(providers))
+	for _, p := range providers {
+		info := p.info
+		info.Fields = append([]ProviderField{}, p.info.Fields...)
+		infos = append(infos, info)
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Name < infos[j].Name
+	})
+
+	return infos
+}
+
+func getProvider(name string) (*provider, error) {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+
+	p, ok := providers[name]
+	if !ok {
+		return nil, fmt.Errorf("unsupported provider: %s", name)
+	}
+
+	return p, nil
+}
+
+func requiredField(name string, label string) ProviderField {
+	return ProviderField{Name: name, Label: label, Required: true}
+}
+
+func optionalField(name string, label string) ProviderField {
+	return ProviderField{Name: name, Label: label}
+}
+
+func otherField(name string, label string, required bool) ProviderField {
+	return ProviderField{Name: name, Label: label, Required: required, Other: true}
+}
@@ -0,0 +1,149 @@
+// Copyright 2024 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_sms_sender
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+const testGateway = "Test Gateway"
+
+// testGatewayClient is the client of testGateway, keeping its config.
+type testGatewayClient struct {
+	fakeClient
+	config *Config
+}
+
+func init() {
+	Register(testGateway, func(config *Config) (SmsClient, error) {
+		return &testGatewayClient{config: config}, nil
+	},
+		requiredField("accessId", "Username"),
+		requiredField("accessKey", "Password"),
+		otherField("apiToken", "API Token", true),
+		otherField("channel", "Channel", false),
+	)
+}
+
+func TestRegisterPanics(t *testing.T) {
+	factory := func(config *Config) (SmsClient, error) {
+		return &fakeClient{}, nil
+	}
+	tests := []struct {
+		name     string
+		provider string
+		factory  ProviderFactory
+		want     string
+	}{
+		{"empty name", "", factory, "name is empty"},
+		{"nil factory", "Nil Factory Gateway", nil, "factory is nil"},
+		{"duplicate built-in", Aliyun, factory, "called twice"},
+		{"duplicate", testGateway, factory, "called twice"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				r := recover()
+				if message, _ := r.(string); !strings.Contains(message, tt.want) {
+					t.Errorf("panic = %v, want %q", r, tt.want)
+				}
+			}()
+			Register(tt.provider, tt.factory)
+		})
+	}
+
+	if _, err := getProvider("Nil Factory Gateway"); err == nil {
+		t.Error("provider with a nil factory was registered")
+	}
+}
+
+func TestListProviders(t *testing.T) {
+	providers := ListProviders()
+
+	names := []string{}
+	fields := map[string][]ProviderField{}
+	for _, info := range providers {
+		names = append(names, info.Name)
+		fields[info.Name] = info.Fields
+	}
+	if !sort.StringsAreSorted(names) {
+		t.Errorf("providers are not sorted: %v", names)
+	}
+	for _, name := range []string{Aliyun, AmazonSNS, MockSms, Twilio, UniSms, testGateway} {
+		if _, ok := fields[name]; !ok {
+			t.Errorf("provider %s is not listed", name)
+		}
+	}
+
+	want := []ProviderField{
+		{Name: "accessId", Label: "Username", Required: true},
+		{Name: "accessKey", Label: "Password", Required: true},
+		{Name: "apiToken", Label: "API Token", Required: true, Other: true},
+		{Name: "channel", Label: "Channel", Other: true},
+	}
+	if !reflect.DeepEqual(fields[testGateway], want) {
+		t.Errorf("fields = %+v, want %+v", fields[testGateway], want)
+	}
+
+	// the fields are copies, changing them does not change the registry
+	fields[testGateway][0].Required = false
+	for _, info := range ListProviders() {
+		if info.Name == testGateway && !info.Fields[0].Required {
+			t.Error("ListProviders returned the registered fields")
+		}
+	}
+}
+
+func TestNewSmsClientRegistered(t *testing.T) {
+	tests := []struct {
+		name      string
+		provider  string
+		other     []string
+		wantExtra map[string]string
+		wantErr   string
+	}{
+		{"other fields in order", testGateway, []string{"token", "otp"}, map[string]string{"apiToken": "token", "channel": "otp"}, ""},
+		{"optional other field left out", testGateway, []string{"token"}, map[string]string{"apiToken": "token"}, ""},
+		{"required other field left out", testGateway, nil, nil, "apiToken"},
+		{"unknown provider", "Unknown SMS", nil, nil, "unsupported provider: Unknown SMS"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := NewSmsClient(tt.provider, "user", "secret", "", "", tt.other...)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			gatewayClient, ok := client.(*testGatewayClient)
+			if !ok {
+				t.Fatalf("client = %T, want the registered factory's", client)
+			}
+			if got := gatewayClient.config; got.AccessId != "user" || got.AccessKey != "secret" || !reflect.DeepEqual(got.Extra, tt.wantExtra) {
+				t.Errorf("config = %+v, want user, secret and %v", got, tt.wantExtra)
+			}
+		})
+	}
+}
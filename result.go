@@ -0,0 +1,113 @@
+// Copyright 2024 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_sms_sender
+
+import "context"
+
+type SendStatus string
+
+const (
+	// SendStatusAccepted means the provider accepted the message for delivery.
+	SendStatusAccepted SendStatus = "accepted"
+	// SendStatusRejected means the provider refused the message for this recipient.
+	SendStatusRejected SendStatus = "rejected"
+	// SendStatusUnknown means the provider response does not tell whether the recipient was accepted.
+	SendStatusUnknown SendStatus = "unknown"
+)
+
+// RecipientResult is the outcome of a send for a single target phone number.
+// Provider is set by clients sending through several providers, such as FailoverClient.
+type RecipientResult struct {
+	PhoneNumber string
+	Provider    string
+	MessageId   string
+	Status      SendStatus
+	Code        string
+	Message     string
+	Fee         string
+	Segments    int
+}
+
+// SendResult is the outcome of one SendMessageResult call.
+type SendResult struct {
+	Provider   string
+	RequestId  string
+	Recipients []RecipientResult
+}
+
+// ResultSmsClient is a SmsClient that reports per-recipient message IDs and status.
+//
+// SendMessageResult may return a non-nil result together with an error, in which
+// case the result tells which recipients were handled before the failure.
+type ResultSmsClient interface {
+	ContextSmsClient
+	SendMessageResult(ctx context.Context, param map[string]string, targetPhoneNumber ...string) (*SendResult, error)
+}
+
+// SendMessageWithResult sends the message and returns its result if the client
+// supports it. For other clients the recipients are reported with an unknown status.
+func SendMessageWithResult(ctx context.Context, client SmsClient, param map[string]string, targetPhoneNumber ...string) (*SendResult, error) {
+	if resultClient, ok := client.(ResultSmsClient); ok {
+		return resultClient.SendMessageResult(ctx, param, targetPhoneNumber...)
+	}
+
+	err := SendMessageWithContext(ctx, client, param, targetPhoneNumber...)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &SendResult{}
+	for _, phoneNumber := range targetPhoneNumber {
+		result.add(phoneNumber, "", SendStatusUnknown)
+	}
+
+	return result, nil
+}
+
+func newSendResult(provider string) *SendResult {
+	return &SendResult{
+		Provider:   provider,
+		Recipients: []RecipientResult{},
+	}
+}
+
+func (r *SendResult) add(phoneNumber string, messageId string, status SendStatus) *RecipientResult {
+	r.Recipients = append(r.Recipients, RecipientResult{
+		PhoneNumber: phoneNumber,
+		MessageId:   messageId,
+		Status:      status,
+	})
+	return &r.Recipients[len(r.Recipients)-1]
+}
+
+// Accepted returns the phone numbers the provider accepted.
+func (r *SendResult) Accepted() []string {
+	return r.phoneNumbers(SendStatusAccepted)
+}
+
+// Rejected returns the phone numbers the provider rejected.
+func (r *SendResult) Rejected() []string {
+	return r.phoneNumbers(SendStatusRejected)
+}
+
+func (r *SendResult) phoneNumbers(status SendStatus) []string {
+	phoneNumbers := []string{}
+	for _, recipient := range r.Recipients {
+		if recipient.Status == status {
+			phoneNumbers = append(phoneNumbers, recipient.PhoneNumber)
+		}
+	}
+	return phoneNumbers
+}
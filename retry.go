@@ -0,0 +1,242 @@
+// Copyright 2024 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_sms_sender
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"syscall"
+	"time"
+
+	"github.com/casdoor/go-sms-sender/phone"
+)
+
+const (
+	defaultRetryMaxAttempts = 3
+	defaultRetryBaseDelay   = 500 * time.Millisecond
+	defaultRetryMaxDelay    = 10 * time.Second
+)
+
+// RetryPolicy configures a RetryClient. Zero values use the defaults:
+// 3 attempts, a 500ms base delay and a 10s maximum delay.
+type RetryPolicy struct {
+	// MaxAttempts is the number of sends including the first one.
+	MaxAttempts int
+	// BaseDelay is doubled after every attempt, a random delay up to it is waited.
+	BaseDelay time.Duration
+	// MaxDelay caps the backoff. A longer Retry-After asked by the provider is
+	// still honored, a longer wait of a RateLimitClient is returned as its error.
+	MaxDelay time.Duration
+}
+
+// RetryClient retries the sends of a client that fail with a transient error,
+// see IsTransient. Recipients accepted by a failed attempt are not sent again
+// when the client implements ResultSmsClient, other clients resend to all. The
+// result reports the recipients accepted by every attempt, and the others as
+// the last attempt left them.
+type RetryClient struct {
+	client SmsClient
+	policy RetryPolicy
+}
+
+var _ ResultSmsClient = &RetryClient{}
+
+func NewRetryClient(client SmsClient, policy RetryPolicy) *RetryClient {
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = defaultRetryMaxAttempts
+	}
+	if policy.BaseDelay <= 0 {
+		policy.BaseDelay = defaultRetryBaseDelay
+	}
+	if policy.MaxDelay <= 0 {
+		policy.MaxDelay = defaultRetryMaxDelay
+	}
+
+	return &RetryClient{
+		client: client,
+		policy: policy,
+	}
+}
+
+func (c *RetryClient) SendMessage(param map[string]string, targetPhoneNumber ...string) error {
+	return c.SendMessageContext(context.Background(), param, targetPhoneNumber...)
+}
+
+func (c *RetryClient) SendMessageContext(ctx context.Context, param map[string]string, targetPhoneNumber ...string) error {
+	_, err := c.SendMessageResult(ctx, param, targetPhoneNumber...)
+	return err
+}
+
+func (c *RetryClient) SendMessageResult(ctx context.Context, param map[string]string, targetPhoneNumber ...string) (*SendResult, error) {
+	result := &SendResult{Recipients: []RecipientResult{}}
+	remaining := targetPhoneNumber
+	for attempt := 1; ; attempt++ {
+		attemptResult, err := SendMessageWithResult(ctx, c.client, param, remaining...)
+
+		// recipients accepted before a failure are kept and not sent again,
+		// the others are reported as the last attempt left them
+		var failed []RecipientResult
+		if attemptResult != nil {
+			result.Provider = attemptResult.Provider
+			result.RequestId = attemptResult.RequestId
+			for _, recipient := range attemptResult.Recipients {
+				if recipient.Status != SendStatusAccepted {
+					failed = append(failed, recipient)
+					continue
+				}
+				result.Recipients = append(result.Recipients, recipient)
+				remaining = withoutRecipient(remaining, recipient.PhoneNumber)
+			}
+		} else if err != nil {
+			for _, phoneNumber := range remaining {
+				failed = append(failed, RecipientResult{PhoneNumber: phoneNumber, Status: SendStatusRejected, Message: err.Error()})
+			}
+		}
+
+		if err == nil || attempt >= c.policy.MaxAttempts || len(remaining) == 0 || !IsTransient(err) {
+			result.Recipients = append(result.Recipients, failed...)
+			return result, err
+		}
+
+		delay, ok := c.backoff(attempt, err)
+		if !ok {
+			result.Recipients = append(result.Recipients, failed...)
+			return result, err
+		}
+		err = sleepContext(ctx, delay)
+		if err != nil {
+			result.Recipients = append(result.Recipients, failed...)
+			return result, err
+		}
+	}
+}
+
+// QueryBalance queries the balance of the wrapped client once, without retries.
+func (c *RetryClient) QueryBalance(ctx context.Context) (*Balance, error) {
+	return QueryBalance(ctx, c.client)
+}
+
+// QueryStatus queries the delivery status through the wrapped client once.
+func (c *RetryClient) QueryStatus(ctx context.Context, query StatusQuery) (*DeliveryReport, error) {
+	return QueryStatus(ctx, c.client, query)
+}
+
+// backoff returns the delay before the next attempt: a random duration up to
+// the exponential backoff, or the provider's Retry-After if it is longer. The
+// wait of a RateLimitClient is honored up to MaxDelay, ok is false when it is
+// longer and the caller should get the error instead.
+func (c *RetryClient) backoff(attempt int, err error) (delay time.Duration, ok bool) {
+	delay = c.policy.BaseDelay << (attempt - 1)
+	if delay <= 0 || delay > c.policy.MaxDelay {
+		delay = c.policy.MaxDelay
+	}
+	delay = time.Duration(rand.Int63n(int64(delay) + 1))
+
+	var smsErr *SmsError
+	if errors.As(err, &smsErr) && smsErr.RetryAfter > delay {
+		delay = smsErr.RetryAfter
+	}
+	var limitErr *RateLimitError
+	if errors.As(err, &limitErr) {
+		if limitErr.RetryAfter > c.policy.MaxDelay {
+			return 0, false
+		}
+		if limitErr.RetryAfter > delay {
+			delay = limitErr.RetryAfter
+		}
+	}
+
+	return delay, true
+}
+
+// IsTransient reports whether err is worth retrying: network errors, rate
+// limiting and provider outages. Cancellation and deadlines are not transient.
+func IsTransient(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	if errors.Is(err, ErrRateLimited) || errors.Is(err, ErrProviderUnavailable) {
+		return true
+	}
+
+	if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) {
+		return true
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// withoutRecipient removes from phoneNumbers the number reported by a
+// provider, which may be formatted differently than the requested one, e.g.
+// 13800138000 for +86 138 0013 8000.
+func withoutRecipient(phoneNumbers []string, reported string) []string {
+	index := -1
+	for i, phoneNumber := range phoneNumbers {
+		if phoneNumber == reported {
+			index = i
+			break
+		}
+		if index < 0 && samePhoneNumber(phoneNumber, reported) {
+			index = i
+		}
+	}
+	if index < 0 {
+		return phoneNumbers
+	}
+
+	result := make([]string, 0, len(phoneNumbers)-1)
+	result = append(result, phoneNumbers[:index]...)
+	return append(result, phoneNumbers[index+1:]...)
+}
+
+// samePhoneNumber reports whether a and b are one number in two formats:
+// E.164 with or without "+" or "00", or the national number of the other.
+func samePhoneNumber(a string, b string) bool {
+	numberA, errA := phone.Parse(a, "")
+	numberB, errB := phone.Parse(b, "")
+	if errA == nil && errB == nil && numberA.E164() == numberB.E164() {
+		return true
+	}
+
+	return errA == nil && sameNationalNumber(b, numberA) || errB == nil && sameNationalNumber(a, numberB)
+}
+
+// sameNationalNumber reports whether national is number in its region's
+// national format.
+func sameNationalNumber(national string, number *phone.Number) bool {
+	if number.Region == "" {
+		return false
+	}
+
+	parsed, err := phone.Parse(national, number.Region)
+	return err == nil && parsed.E164() == number.E164()
+}
+
+func sleepContext(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,258 @@
+// Copyright 2024 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_sms_sender
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeReply scripts one send of a fakeClient: the numbers in rejected are
+// reported as rejected, the others as accepted in the format returned by
+// format, and err is returned with the result, or alone with noResult.
+type fakeReply struct {
+	rejected []string
+	format   func(phoneNumber string) string
+	err      error
+	noResult bool
+}
+
+// fakeClient answers its sends with replies in order, accepting every
+// recipient once they run out, and records the recipients of each send.
+type fakeClient struct {
+	mu      sync.Mutex
+	replies []fakeReply
+	calls   [][]string
+}
+
+func (c *fakeClient) SendMessage(param map[string]string, targetPhoneNumber ...string) error {
+	return c.SendMessageContext(context.Background(), param, targetPhoneNumber...)
+}
+
+func (c *fakeClient) SendMessageContext(ctx context.Context, param map[string]string, targetPhoneNumber ...string) error {
+	_, err := c.SendMessageResult(ctx, param, targetPhoneNumber...)
+	return err
+}
+
+func (c *fakeClient) SendMessageResult(ctx context.Context, param map[string]string, targetPhoneNumber ...string) (*SendResult, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.calls = append(c.calls, append([]string{}, targetPhoneNumber...))
+	var reply fakeReply
+	if len(c.replies) > 0 {
+		reply, c.replies = c.replies[0], c.replies[1:]
+	}
+	if reply.noResult {
+		return nil, reply.err
+	}
+
+	result := newSendResult("fake")
+	for _, phoneNumber := range targetPhoneNumber {
+		status := SendStatusAccepted
+		for _, rejected := range reply.rejected {
+			if rejected == phoneNumber {
+				status = SendStatusRejected
+			}
+		}
+
+		reported := phoneNumber
+		if reply.format != nil {
+			reported = reply.format(phoneNumber)
+		}
+		result.add(reported, "", status)
+	}
+	return result, reply.err
+}
+
+var (
+	errUnavailable = &SmsError{Provider: "fake", Code: "503", Kind: ErrProviderUnavailable}
+	errBadNumber   = &SmsError{Provider: "fake", Code: "400", Kind: ErrInvalidNumber}
+)
+
+func chineseNational(phoneNumber string) string {
+	return strings.TrimPrefix(phoneNumber, "+86")
+}
+
+func TestRetryClientPartialSuccess(t *testing.T) {
+	a, b, c := "+8613800138000", "+8613900139000", "+8613700137000"
+	tests := []struct {
+		name         string
+		replies      []fakeReply
+		wantCalls    [][]string
+		wantAccepted []string
+		wantRejected []string
+		wantErr      error
+	}{
+		{
+			name:         "first attempt succeeds",
+			wantCalls:    [][]string{{a, b, c}},
+			wantAccepted: []string{a, b, c},
+		},
+		{
+			name:         "accepted recipients are not sent again",
+			replies:      []fakeReply{{rejected: []string{b, c}, err: errUnavailable}, {rejected: []string{c}, err: errUnavailable}},
+			wantCalls:    [][]string{{a, b, c}, {b, c}, {c}},
+			wantAccepted: []string{a, b, c},
+		},
+		{
+			name:         "recipients reported in national format",
+			replies:      []fakeReply{{rejected: []string{b}, format: chineseNational, err: errUnavailable}},
+			wantCalls:    [][]string{{a, b, c}, {b}},
+			wantAccepted: []string{"13800138000", "13700137000", b},
+		},
+		{
+			name:         "send without result is retried to all",
+			replies:      []fakeReply{{noResult: true, err: errUnavailable}},
+			wantCalls:    [][]string{{a, b, c}, {a, b, c}},
+			wantAccepted: []string{a, b, c},
+		},
+		{
+			name:         "permanent error is not retried",
+			replies:      []fakeReply{{rejected: []string{b}, err: errBadNumber}},
+			wantCalls:    [][]string{{a, b, c}},
+			wantAccepted: []string{a, c},
+			wantRejected: []string{b},
+			wantErr:      ErrInvalidNumber,
+		},
+		{
+			name: "attempts run out",
+			replies: []fakeReply{
+				{rejected: []string{b, c}, err: errUnavailable},
+				{rejected: []string{b, c}, err: errUnavailable},
+				{rejected: []string{b, c}, err: errUnavailable},
+			},
+			wantCalls:    [][]string{{a, b, c}, {b, c}, {b, c}},
+			wantAccepted: []string{a},
+			wantRejected: []string{b, c},
+			wantErr:      ErrProviderUnavailable,
+		},
+		{
+			name: "last attempt without result",
+			replies: []fakeReply{
+				{rejected: []string{b}, err: errUnavailable},
+				{noResult: true, err: errUnavailable},
+				{noResult: true, err: errUnavailable},
+			},
+			wantCalls:    [][]string{{a, b, c}, {b}, {b}},
+			wantAccepted: []string{a, c},
+			wantRejected: []string{b},
+			wantErr:      ErrProviderUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakeClient{replies: tt.replies}
+			retry := NewRetryClient(client, RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond})
+
+			result, err := retry.SendMessageResult(context.Background(), nil, a, b, c)
+			if !errors.Is(err, tt.wantErr) || (err != nil) != (tt.wantErr != nil) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(client.calls, tt.wantCalls) {
+				t.Errorf("calls = %v, want %v", client.calls, tt.wantCalls)
+			}
+			if got := result.Accepted(); !reflect.DeepEqual(got, tt.wantAccepted) {
+				t.Errorf("accepted = %v, want %v", got, tt.wantAccepted)
+			}
+			if got := result.Rejected(); len(got)+len(tt.wantRejected) > 0 && !reflect.DeepEqual(got, tt.wantRejected) {
+				t.Errorf("rejected = %v, want %v", got, tt.wantRejected)
+			}
+		})
+	}
+}
+
+func TestRetryClientBackoff(t *testing.T) {
+	retry := NewRetryClient(&fakeClient{}, RetryPolicy{BaseDelay: time.Second, MaxDelay: 4 * time.Second})
+	tests := []struct {
+		name    string
+		attempt int
+		err     error
+		min     time.Duration
+		max     time.Duration
+		wantOk  bool
+	}{
+		{"first attempt", 1, errUnavailable, 0, time.Second, true},
+		{"doubled", 3, errUnavailable, 0, 4 * time.Second, true},
+		{"capped", 10, errUnavailable, 0, 4 * time.Second, true},
+		{"provider retry after", 1, &SmsError{Kind: ErrRateLimited, RetryAfter: time.Minute}, time.Minute, time.Minute, true},
+		{"short rate limit wait", 1, &RateLimitError{Key: "account", RetryAfter: 3 * time.Second}, 3 * time.Second, 3 * time.Second, true},
+		{"long rate limit wait", 1, &RateLimitError{Key: "account", RetryAfter: time.Hour}, 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := retry.backoff(tt.attempt, tt.err)
+			if ok != tt.wantOk || got < tt.min || got > tt.max {
+				t.Errorf("backoff(%d, %v) = %s, %t, want between %s and %s, %t", tt.attempt, tt.err, got, ok, tt.min, tt.max, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestRetryClientOverRateLimitClient(t *testing.T) {
+	client := &fakeClient{}
+	limiter := NewRateLimitClient(client, RateLimitPolicy{AccountLimits: []RateLimit{{1, 24 * time.Hour}}})
+	retry := NewRetryClient(limiter, RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Second})
+
+	if err := retry.SendMessage(nil, "+8613800138000"); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	err := retry.SendMessage(nil, "+8613900139000")
+	var limitErr *RateLimitError
+	if !errors.As(err, &limitErr) {
+		t.Fatalf("error = %v, want a *RateLimitError", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("send waited %s for a daily limit", elapsed)
+	}
+	if len(client.calls) != 1 {
+		t.Errorf("calls = %v, want only the first send", client.calls)
+	}
+}
+
+func TestWithoutRecipient(t *testing.T) {
+	tests := []struct {
+		name         string
+		phoneNumbers []string
+		reported     string
+		want         []string
+	}{
+		{"exact", []string{"+8613800138000", "+8613900139000"}, "+8613900139000", []string{"+8613800138000"}},
+		{"digits", []string{"+8613800138000", "+8613900139000"}, "8613800138000", []string{"+8613900139000"}},
+		{"00 prefix", []string{"+905321234567"}, "00905321234567", []string{}},
+		{"national", []string{"+8613800138000", "+8613900139000"}, "13900139000", []string{"+8613800138000"}},
+		{"national requested", []string{"05321234567", "+905321234568"}, "+905321234567", []string{"+905321234568"}},
+		{"exact match first", []string{"8613800138000", "+8613800138000"}, "+8613800138000", []string{"8613800138000"}},
+		{"one of duplicates", []string{"+12065550100", "+12065550100"}, "+12065550100", []string{"+12065550100"}},
+		{"unknown", []string{"+12065550100"}, "+12065550101", []string{"+12065550100"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := withoutRecipient(tt.phoneNumbers, tt.reported); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("withoutRecipient(%v, %q) = %v, want %v", tt.phoneNumbers, tt.reported, got, tt.want)
+			}
+		})
+	}
+}
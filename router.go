@@ -0,0 +1,166 @@
+// Copyright 2024 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_sms_sender
+
+import (
+	"context"
+	"sort"
+	"strings"
+)
+
+// Route sends to the phone numbers starting with Prefix, an E.164 country
+// calling code such as "+86" or any longer prefix such as "+1684".
+// Translate is optional, Name defaults to the provider reported by Client.
+type Route struct {
+	Prefix    string
+	Name      string
+	Client    SmsClient
+	Translate ParamTranslator
+}
+
+// Router picks the client of every target phone number by the longest
+// matching Route prefix, and sends one batch per route. Numbers matching no
+// route, or not in international format, go to the default route.
+type Router struct {
+	routes       []Route
+	defaultRoute *Route
+}
+
+var _ ResultSmsClient = &Router{}
+
+// NewRouter creates a Router, defaultRoute may be nil to reject the numbers
+// matching no route with ErrInvalidNumber.
+func NewRouter(defaultRoute *Route, routes ...Route) *Router {
+	sorted := make([]Route, 0, len(routes))
+	for _, route := range routes {
+		route.Prefix = "+" + strings.TrimPrefix(route.Prefix, "+")
+		sorted = append(sorted, route)
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return len(sorted[i].Prefix) > len(sorted[j].Prefix)
+	})
+
+	return &Router{
+		routes:       sorted,
+		defaultRoute: defaultRoute,
+	}
+}
+
+func (r *Router) SendMessage(param map[string]string, targetPhoneNumber ...string) error {
+	return r.SendMessageContext(context.Background(), param, targetPhoneNumber...)
+}
+
+func (r *Router) SendMessageContext(ctx context.Context, param map[string]string, targetPhoneNumber ...string) error {
+	_, err := r.SendMessageResult(ctx, param, targetPhoneNumber...)
+	return err
+}
+
+// SendMessageResult sends every batch even if another one fails, and returns
+// the error of the first failed batch.
+func (r *Router) SendMessageResult(ctx context.Context, param map[string]string, targetPhoneNumber ...string) (*SendResult, error) {
+	if len(targetPhoneNumber) == 0 {
+		return nil, missingParameterError("targetPhoneNumber")
+	}
+
+	routes := []*Route{}
+	batches := map[*Route][]string{}
+	result := &SendResult{Recipients: []RecipientResult{}}
+	var firstErr error
+	for _, phoneNumber := range targetPhoneNumber {
+		route := r.match(phoneNumber)
+		if route == nil {
+			err := &SmsError{Message: "no route for " + phoneNumber, Kind: ErrInvalidNumber}
+			result.add(phoneNumber, "", SendStatusRejected).Message = err.Message
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+
+		if _, ok := batches[route]; !ok {
+			routes = append(routes, route)
+		}
+		batches[route] = append(batches[route], phoneNumber)
+	}
+
+	for _, route := range routes {
+		routeParam := param
+		if route.Translate != nil {
+			routeParam = route.Translate(param)
+		}
+
+		routeResult, err := SendMessageWithResult(ctx, route.Client, routeParam, batches[route]...)
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+		if routeResult == nil {
+			for _, phoneNumber := range batches[route] {
+				recipient := result.add(phoneNumber, "", SendStatusRejected)
+				recipient.Provider = route.Name
+				if err != nil {
+					recipient.Message = err.Error()
+				}
+			}
+			continue
+		}
+
+		name := route.Name
+		if name == "" {
+			name = routeResult.Provider
+		}
+		if len(routes) == 1 {
+			result.Provider = name
+			result.RequestId = routeResult.RequestId
+		}
+		for _, recipient := range routeResult.Recipients {
+			if recipient.Provider == "" {
+				recipient.Provider = name
+			}
+			result.Recipients = append(result.Recipients, recipient)
+		}
+	}
+
+	return result, firstErr
+}
+
+func (r *Router) match(phoneNumber string) *Route {
+	number := routingNumber(phoneNumber)
+	if strings.HasPrefix(number, "+") {
+		for i := range r.routes {
+			if strings.HasPrefix(number, r.routes[i].Prefix) {
+				return &r.routes[i]
+			}
+		}
+	}
+
+	return r.defaultRoute
+}
+
+// routingNumber drops the separators of a phone number and turns a leading
+// "00" international prefix into "+".
+func routingNumber(phoneNumber string) string {
+	number := strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '.', '(', ')':
+			return -1
+		}
+		return r
+	}, phoneNumber)
+
+	if strings.HasPrefix(number, "00") {
+		number = "+" + number[2:]
+	}
+	return number
+}
@@ -0,0 +1,133 @@
+// Copyright 2024 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_sms_sender
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestRouterBatching(t *testing.T) {
+	cn1, cn2, us, as := "+8613800138000", "+8613900139000", "+12065550100", "+16846331234"
+	tests := []struct {
+		name           string
+		noDefault      bool
+		replies        map[string][]fakeReply
+		phoneNumbers   []string
+		wantCalls      map[string][][]string
+		wantRecipients []string
+		wantErr        error
+	}{
+		{
+			name:         "one batch per route",
+			phoneNumbers: []string{cn1, us, cn2, as},
+			wantCalls: map[string][][]string{
+				"cn": {{cn1, cn2}},
+				"us": {{us}},
+				"as": {{as}},
+			},
+			wantRecipients: []string{cn1 + " cn accepted", cn2 + " cn accepted", us + " us accepted", as + " as accepted"},
+		},
+		{
+			name:           "separators and 00 prefix",
+			phoneNumbers:   []string{"0086 138-0013-8000", "+1 (684) 633-1234"},
+			wantCalls:      map[string][][]string{"cn": {{"0086 138-0013-8000"}}, "as": {{"+1 (684) 633-1234"}}},
+			wantRecipients: []string{"0086 138-0013-8000 cn accepted", "+1 (684) 633-1234 as accepted"},
+		},
+		{
+			name:           "unmatched and national numbers go to the default route",
+			phoneNumbers:   []string{"+905321234567", "13800138000", cn1},
+			wantCalls:      map[string][][]string{"default": {{"+905321234567", "13800138000"}}, "cn": {{cn1}}},
+			wantRecipients: []string{"+905321234567 default accepted", "13800138000 default accepted", cn1 + " cn accepted"},
+		},
+		{
+			name:           "no default route",
+			noDefault:      true,
+			phoneNumbers:   []string{"+905321234567", us},
+			wantCalls:      map[string][][]string{"us": {{us}}},
+			wantRecipients: []string{"+905321234567  rejected", us + " us accepted"},
+			wantErr:        ErrInvalidNumber,
+		},
+		{
+			name:           "failed batch does not stop the others",
+			replies:        map[string][]fakeReply{"cn": {{noResult: true, err: errUnavailable}}},
+			phoneNumbers:   []string{cn1, us, cn2},
+			wantCalls:      map[string][][]string{"cn": {{cn1, cn2}}, "us": {{us}}},
+			wantRecipients: []string{cn1 + " cn rejected", cn2 + " cn rejected", us + " us accepted"},
+			wantErr:        ErrProviderUnavailable,
+		},
+		{
+			name:           "partial batch",
+			replies:        map[string][]fakeReply{"cn": {{rejected: []string{cn2}, err: errBadNumber}}},
+			phoneNumbers:   []string{cn1, cn2, us},
+			wantCalls:      map[string][][]string{"cn": {{cn1, cn2}}, "us": {{us}}},
+			wantRecipients: []string{cn1 + " cn accepted", cn2 + " cn rejected", us + " us accepted"},
+			wantErr:        ErrInvalidNumber,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clients := map[string]*fakeClient{}
+			for _, name := range []string{"cn", "us", "as", "default"} {
+				clients[name] = &fakeClient{replies: tt.replies[name]}
+			}
+			defaultRoute := &Route{Name: "default", Client: clients["default"]}
+			if tt.noDefault {
+				defaultRoute = nil
+			}
+			router := NewRouter(defaultRoute,
+				Route{Prefix: "+1", Name: "us", Client: clients["us"]},
+				Route{Prefix: "86", Name: "cn", Client: clients["cn"]},
+				Route{Prefix: "+1684", Name: "as", Client: clients["as"]},
+			)
+
+			result, err := router.SendMessageResult(context.Background(), nil, tt.phoneNumbers...)
+			if !errors.Is(err, tt.wantErr) || (err != nil) != (tt.wantErr != nil) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			for name, client := range clients {
+				if !reflect.DeepEqual(client.calls, tt.wantCalls[name]) {
+					t.Errorf("%s calls = %v, want %v", name, client.calls, tt.wantCalls[name])
+				}
+			}
+			if got := recipientSummary(result); !reflect.DeepEqual(got, tt.wantRecipients) {
+				t.Errorf("recipients = %v, want %v", got, tt.wantRecipients)
+			}
+		})
+	}
+}
+
+func TestRouterTranslate(t *testing.T) {
+	var got map[string]string
+	client := &fakeClient{}
+	router := NewRouter(nil, Route{
+		Prefix: "+86",
+		Client: client,
+		Translate: func(param map[string]string) map[string]string {
+			got = RenameParams(map[string]string{"code": "0"})(param)
+			return got
+		},
+	})
+
+	if err := router.SendMessage(map[string]string{"code": "123456"}, "+8613800138000"); err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]string{"0": "123456"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("translated param = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,178 @@
+// Copyright 2024 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_sms_sender
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf16"
+)
+
+type Encoding string
+
+const (
+	GSM7 Encoding = "GSM-7"
+	UCS2 Encoding = "UCS-2"
+)
+
+const (
+	gsm7SegmentLength       = 160
+	gsm7MultiSegmentLength  = 153
+	ucs2SegmentLength       = 70
+	ucs2MultiSegmentLength  = 67
+	gsm7BasicCharacters     = "@£$¥èéùìòÇ\nØø\rÅåΔ_ΦΓΛΩΠΨΣΘΞÆæßÉ !\"#¤%&'()*+,-./0123456789:;<=>?¡ABCDEFGHIJKLMNOPQRSTUVWXYZÄÖÑÜ§¿abcdefghijklmnopqrstuvwxyzäöñüà"
+	gsm7ExtensionCharacters = "\f^{}\\[~]|€"
+)
+
+// MessageInfo describes how a message body is sent. Length is in septets for
+// GSM-7, where extension table characters take two, and in UTF-16 code units
+// for UCS-2. Messages longer than one segment are split into parts carrying a
+// concatenation header, which leaves 153 septets or 67 code units per part.
+type MessageInfo struct {
+	Encoding Encoding
+	Length   int
+	Segments int
+}
+
+// AnalyzeMessage detects the encoding of body and counts its segments.
+func AnalyzeMessage(body string) MessageInfo {
+	if isGSM7(body) {
+		lengths := []int{}
+		for _, r := range body {
+			if strings.ContainsRune(gsm7ExtensionCharacters, r) {
+				lengths = append(lengths, 2)
+			} else {
+				lengths = append(lengths, 1)
+			}
+		}
+		return countSegments(GSM7, lengths, gsm7SegmentLength, gsm7MultiSegmentLength)
+	}
+
+	lengths := []int{}
+	for _, r := range body {
+		lengths = append(lengths, len(utf16.Encode([]rune{r})))
+	}
+	return countSegments(UCS2, lengths, ucs2SegmentLength, ucs2MultiSegmentLength)
+}
+
+func isGSM7(body string) bool {
+	for _, r := range body {
+		if !strings.ContainsRune(gsm7BasicCharacters, r) && !strings.ContainsRune(gsm7ExtensionCharacters, r) {
+			return false
+		}
+	}
+	return true
+}
+
+// countSegments packs the characters into segments, never splitting an escape
+// sequence or a surrogate pair across two of them.
+func countSegments(encoding Encoding, lengths []int, segmentLength int, multiSegmentLength int) MessageInfo {
+	info := MessageInfo{Encoding: encoding, Segments: 1}
+	for _, length := range lengths {
+		info.Length += length
+	}
+	if info.Length <= segmentLength {
+		return info
+	}
+
+	used := 0
+	for _, length := range lengths {
+		if used+length > multiSegmentLength {
+			info.Segments++
+			used = 0
+		}
+		used += length
+	}
+	return info
+}
+
+// SegmentPolicy limits the segments of the bodies sent by the providers
+// sending plain text. With Transliterate, a body that is not GSM-7 only
+// because of characters such as curly quotes, dashes or accented letters has
+// them replaced by GSM-7 lookalikes. A body still needing more than
+// MaxSegments is rejected with ErrContentRejected before sending.
+type SegmentPolicy struct {
+	MaxSegments   int
+	Transliterate bool
+}
+
+// SegmentPolicySetter is implemented by the clients sending plain text.
+type SegmentPolicySetter interface {
+	SetSegmentPolicy(policy SegmentPolicy)
+}
+
+// Apply returns body as it should be sent under the policy.
+func (p SegmentPolicy) Apply(body string) (string, error) {
+	if p.Transliterate && !isGSM7(body) {
+		if transliterated := Transliterate(body); isGSM7(transliterated) {
+			body = transliterated
+		}
+	}
+
+	if p.MaxSegments > 0 {
+		info := AnalyzeMessage(body)
+		if info.Segments > p.MaxSegments {
+			return "", &SmsError{
+				Message: fmt.Sprintf("message needs %d %s segments, more than the limit of %d", info.Segments, info.Encoding, p.MaxSegments),
+				Kind:    ErrContentRejected,
+			}
+		}
+	}
+
+	return body, nil
+}
+
+var transliterations = map[rune]string{
+	'‘': "'", '’': "'", '‚': "'", '′': "'", '`': "'", '´': "'",
+	'“': "\"", '”': "\"", '„': "\"", '″': "\"", '«': "\"", '»': "\"",
+	'–': "-", '—': "-", '−': "-", '‐': "-",
+	'…': "...", '•': "*", '\t': " ",
+	'\u00a0': " ", '\u2002': " ", '\u2003': " ", '\u2009': " ", '\u202f': " ",
+	'á': "a", 'â': "a", 'ã': "a", 'ā': "a", 'ą': "a", 'ă': "a",
+	'Á': "A", 'À': "A", 'Â': "A", 'Ã': "A", 'Ā': "A", 'Ą': "A", 'Ă': "A",
+	'ç': "c", 'ć': "c", 'č': "c", 'Ć': "C", 'Č': "C",
+	'ď': "d", 'Ď': "D", 'đ': "d", 'Đ': "D",
+	'ê': "e", 'ë': "e", 'ē': "e", 'ę': "e", 'ě': "e",
+	'È': "E", 'Ê': "E", 'Ë': "E", 'Ē': "E", 'Ę': "E", 'Ě': "E",
+	'ğ': "g", 'Ğ': "G",
+	'í': "i", 'î': "i", 'ï': "i", 'ī': "i", 'ı': "i",
+	'Í': "I", 'Ì': "I", 'Î': "I", 'Ï': "I", 'Ī': "I", 'İ': "I",
+	'ł': "l", 'Ł': "L", 'ľ': "l", 'Ľ': "L",
+	'ń': "n", 'ň': "n", 'Ń': "N", 'Ň': "N",
+	'ó': "o", 'ô': "o", 'õ': "o", 'ő': "o", 'ō': "o",
+	'Ó': "O", 'Ò': "O", 'Ô': "O", 'Õ': "O", 'Ő': "O", 'Ō': "O",
+	'œ': "oe", 'Œ': "OE",
+	'ř': "r", 'Ř': "R",
+	'ś': "s", 'š': "s", 'ş': "s", 'ș': "s", 'Ś': "S", 'Š': "S", 'Ş': "S", 'Ș': "S",
+	'ť': "t", 'ţ': "t", 'ț': "t", 'Ť': "T", 'Ţ': "T", 'Ț': "T",
+	'ú': "u", 'û': "u", 'ű': "u", 'ů': "u", 'ū': "u",
+	'Ú': "U", 'Ù': "U", 'Û': "U", 'Ű': "U", 'Ů': "U", 'Ū': "U",
+	'ý': "y", 'ÿ': "y", 'Ý': "Y", 'Ÿ': "Y",
+	'ź': "z", 'ž': "z", 'ż': "z", 'Ź': "Z", 'Ž': "Z", 'Ż': "Z",
+}
+
+// Transliterate replaces the characters outside GSM-7 that have a GSM-7
+// lookalike, and keeps the others.
+func Transliterate(body string) string {
+	var b strings.Builder
+	for _, r := range body {
+		if replacement, ok := transliterations[r]; ok && !strings.ContainsRune(gsm7BasicCharacters, r) {
+			b.WriteString(replacement)
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
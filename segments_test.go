@@ -0,0 +1,81 @@
+// Copyright 2024 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_sms_sender
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestAnalyzeMessage(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want MessageInfo
+	}{
+		{"empty", "", MessageInfo{GSM7, 0, 1}},
+		{"ascii", "Your code is 123456", MessageInfo{GSM7, 19, 1}},
+		{"accent outside gsm7", "Ça coûte 5€", MessageInfo{UCS2, 11, 1}},
+		{"extension characters", "{code}€", MessageInfo{GSM7, 10, 1}},
+		{"gsm7 single segment", strings.Repeat("a", 160), MessageInfo{GSM7, 160, 1}},
+		{"gsm7 two segments", strings.Repeat("a", 161), MessageInfo{GSM7, 161, 2}},
+		{"gsm7 three segments", strings.Repeat("a", 307), MessageInfo{GSM7, 307, 3}},
+		{"escape not split", strings.Repeat("a", 152) + "€" + strings.Repeat("a", 10), MessageInfo{GSM7, 164, 2}},
+		{"curly quote", "It’s 123456", MessageInfo{UCS2, 11, 1}},
+		{"chinese", "您的验证码是123456", MessageInfo{UCS2, 12, 1}},
+		{"ucs2 single segment", strings.Repeat("码", 70), MessageInfo{UCS2, 70, 1}},
+		{"ucs2 two segments", strings.Repeat("码", 71), MessageInfo{UCS2, 71, 2}},
+		{"surrogate pair", "😀", MessageInfo{UCS2, 2, 1}},
+		{"surrogate pair not split", strings.Repeat("码", 66) + "😀" + strings.Repeat("码", 3), MessageInfo{UCS2, 71, 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := AnalyzeMessage(tt.body); got != tt.want {
+				t.Errorf("AnalyzeMessage(%q) = %+v, want %+v", tt.body, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSegmentPolicyApply(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  SegmentPolicy
+		body    string
+		want    string
+		wantErr error
+	}{
+		{"no policy", SegmentPolicy{}, "It’s 123456", "It’s 123456", nil},
+		{"transliterate", SegmentPolicy{Transliterate: true}, "It’s “123456” – ok", "It's \"123456\" - ok", nil},
+		{"transliterate keeps chinese", SegmentPolicy{Transliterate: true}, "验证码 “123456”", "验证码 “123456”", nil},
+		{"within limit", SegmentPolicy{MaxSegments: 1}, strings.Repeat("a", 160), strings.Repeat("a", 160), nil},
+		{"over limit", SegmentPolicy{MaxSegments: 1}, strings.Repeat("a", 161), "", ErrContentRejected},
+		{"transliterated within limit", SegmentPolicy{MaxSegments: 1, Transliterate: true}, strings.Repeat("’", 100), strings.Repeat("'", 100), nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.policy.Apply(tt.body)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Apply(%q) error = %v, want %v", tt.body, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Apply(%q) = %q, want %q", tt.body, got, tt.want)
+			}
+		})
+	}
+}
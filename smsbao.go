@@ -15,6 +15,7 @@
 package go_sms_sender
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"net/http"
@@ -22,12 +23,30 @@ import (
 	"strings"
 )
 
+const smsbaoEndpoint = "https://api.smsbao.com"
+
 type SmsBaoClient struct {
-	username string
-	apikey   string
-	sign     string
-	template string
-	goodsid  string
+	endpoint   string
+	username   string
+	apikey     string
+	sign       string
+	template   *MessageTemplate
+	segments   SegmentPolicy
+	goodsid    string
+	httpClient *http.Client
+}
+
+func init() {
+	Register(SmsBao, func(config *Config) (SmsClient, error) {
+		return GetSmsbaoClient(config.AccessId, config.AccessKey, config.Sign, config.Template, []string{config.GoodsId})
+	},
+		requiredField("accessId", "Username"),
+		requiredField("accessKey", "API Key"),
+		requiredField("sign", "Sign Name"),
+		requiredField("template", "Template"),
+		otherField("goodsId", "Goods ID", false),
+		optionalField("endpoint", "Endpoint"),
+	)
 }
 
 func GetSmsbaoClient(username string, apikey string, sign string, template string, other []string) (*SmsBaoClient, error) {
@@ -38,60 +57,173 @@ func GetSmsbaoClient(username string, apikey string, sign string, template strin
 		goodsid = other[0]
 	}
 	return &SmsBaoClient{
-		username: username,
-		apikey:   apikey,
-		sign:     sign,
-		template: template,
-		goodsid:  goodsid,
+		endpoint:   smsbaoEndpoint,
+		username:   username,
+		apikey:     apikey,
+		sign:       sign,
+		template:   NewMessageTemplate(template),
+		goodsid:    goodsid,
+		httpClient: newDefaultHttpClient(),
 	}, nil
 }
 
+func (c *SmsBaoClient) SetHttpClient(httpClient *http.Client) {
+	c.httpClient = httpClient
+}
+
+func (c *SmsBaoClient) SetTemplate(template *MessageTemplate) {
+	c.template = template
+}
+
+func (c *SmsBaoClient) SetSegmentPolicy(policy SegmentPolicy) {
+	c.segments = policy
+}
+
+func (c *SmsBaoClient) SetEndpoint(endpoint string) {
+	c.endpoint = strings.TrimSuffix(endpoint, "/")
+}
+
 func (c *SmsBaoClient) SendMessage(param map[string]string, targetPhoneNumber ...string) error {
-	code, ok := param["code"]
-	if !ok {
-		return fmt.Errorf("missing parameter: code")
-	}
+	return c.SendMessageContext(context.Background(), param, targetPhoneNumber...)
+}
+
+func (c *SmsBaoClient) SendMessageContext(ctx context.Context, param map[string]string, targetPhoneNumber ...string) error {
+	_, err := c.SendMessageResult(ctx, param, targetPhoneNumber...)
+	return err
+}
 
+func (c *SmsBaoClient) SendMessageResult(ctx context.Context, param map[string]string, targetPhoneNumber ...string) (*SendResult, error) {
 	if len(targetPhoneNumber) == 0 {
-		return fmt.Errorf("missing parameter: targetPhoneNumber")
+		return nil, missingParameterError("targetPhoneNumber")
 	}
 
-	smsContent := url.QueryEscape("【" + c.sign + "】" + fmt.Sprintf(c.template, code))
-	for _, mobile := range targetPhoneNumber {
-		if strings.HasPrefix(mobile, "+86") {
-			mobile = mobile[3:]
-		} else if strings.HasPrefix(mobile, "+") {
-			return fmt.Errorf("unsupported country code")
+	// the sign is part of the message and counts for its segments
+	bodies, err := renderBodies(c.template, SegmentPolicy{}, param, targetPhoneNumber)
+	if err != nil {
+		return nil, err
+	}
+	for i := range bodies {
+		bodies[i], err = c.segments.Apply("【" + c.sign + "】" + bodies[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	result := newSendResult(SmsBao)
+	for i, phoneNumber := range targetPhoneNumber {
+		smsContent := url.QueryEscape(bodies[i])
+		mobile, err := FormatPhoneNumber(SmsBao, phoneNumber)
+		if err != nil {
+			result.add(phoneNumber, "", SendStatusRejected).Message = err.Error()
+			return result, err
 		}
 		// https://api.smsbao.com/sms?u=USERNAME&p=PASSWORD&g=GOODSID&m=PHONE&c=CONTENT
-		url := fmt.Sprintf("https://api.smsbao.com/sms?u=%s&p=%s&g=%s&m=%s&c=%s", c.username, c.apikey, c.goodsid, mobile, smsContent)
+		url := fmt.Sprintf("%s/sms?u=%s&p=%s&g=%s&m=%s&c=%s", c.endpoint, c.username, c.apikey, c.goodsid, mobile, smsContent)
 
-		client := &http.Client{}
-		req, _ := http.NewRequest("GET", url, nil)
-		resp, err := client.Do(req)
+		req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+		if err != nil {
+			return result, err
+		}
+		resp, err := c.httpClient.Do(req)
 		if err != nil {
-			return err
+			return result, err
 		}
 		defer resp.Body.Close()
 		body, err := io.ReadAll(resp.Body)
 		if err != nil {
-			return err
+			return result, err
 		}
-		switch string(body) {
-		case "30":
-			return fmt.Errorf("password error")
-		case "40":
-			return fmt.Errorf("account not exist")
-		case "41":
-			return fmt.Errorf("overdue account")
-		case "43":
-			return fmt.Errorf("IP address limit")
-		case "50":
-			return fmt.Errorf("content contain forbidden words")
-		case "51":
-			return fmt.Errorf("phone number incorrect")
+
+		respCode := strings.TrimSpace(string(body))
+		err = getSmsbaoError(respCode)
+		if err != nil {
+			recipient := result.add(phoneNumber, "", SendStatusRejected)
+			recipient.Code = respCode
+			recipient.Message = err.Error()
+			return result, err
 		}
+
+		result.add(phoneNumber, "", SendStatusAccepted).Code = respCode
+	}
+
+	return result, nil
+}
+
+var smsbaoErrors = map[string]error{
+	"-1": ErrMissingParameter,
+	"30": ErrInvalidCredentials,
+	"40": ErrInvalidCredentials,
+	"41": ErrInsufficientBalance,
+	"43": ErrInvalidCredentials,
+	"50": ErrContentRejected,
+	"51": ErrInvalidNumber,
+}
+
+func getSmsbaoError(code string) error {
+	var message string
+	switch code {
+	case "0":
+		return nil
+	case "-1":
+		message = "parameters incomplete"
+	case "30":
+		message = "password error"
+	case "40":
+		message = "account not exist"
+	case "41":
+		message = "overdue account"
+	case "43":
+		message = "IP address limit"
+	case "50":
+		message = "content contain forbidden words"
+	case "51":
+		message = "phone number incorrect"
+	default:
+		message = "send message failed"
+	}
+
+	return newSmsError(SmsBao, code, message, smsbaoErrors)
+}
+
+// QueryBalance queries the message count left, the answer is the result code
+// and then a line with the sent and remaining counts, e.g. "0\n100,200".
+func (c *SmsBaoClient) QueryBalance(ctx context.Context) (*Balance, error) {
+	// https://api.smsbao.com/query?u=USERNAME&p=PASSWORD
+	url := fmt.Sprintf("%s/query?u=%s&p=%s", c.endpoint, c.username, c.apikey)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := readResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+	if err = checkHttpStatus(SmsBao, resp, body); err != nil {
+		return nil, err
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(body)), "\n")
+	respCode := strings.TrimSpace(lines[0])
+	if err = getSmsbaoError(respCode); err != nil {
+		return nil, err
+	}
+
+	counts := []string{}
+	if len(lines) > 1 {
+		counts = strings.Split(lines[1], ",")
+	}
+	if len(counts) != 2 {
+		return nil, fmt.Errorf("unexpected smsbao balance response: %q", string(body))
+	}
+	remaining, err := parseMessageCount(counts[1])
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	return newMessageBalance(SmsBao, remaining), nil
 }
@@ -0,0 +1,50 @@
+// Copyright 2024 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_sms_sender
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var ErrMessageNotFound = errors.New("message not found")
+
+// StatusQuery identifies a sent message by the MessageId of its
+// RecipientResult. The providers searching by recipient and time, such as
+// Aliyun and Tencent Cloud, also need PhoneNumber and, if the message is not
+// from today, SentAt.
+type StatusQuery struct {
+	MessageId   string
+	PhoneNumber string
+	SentAt      time.Time
+}
+
+// StatusQuerier is implemented by the clients that can look up the delivery
+// status of a sent message, for polling where webhooks cannot be exposed.
+type StatusQuerier interface {
+	QueryStatus(ctx context.Context, query StatusQuery) (*DeliveryReport, error)
+}
+
+// QueryStatus queries the delivery status of a message sent by client.
+func QueryStatus(ctx context.Context, client SmsClient, query StatusQuery) (*DeliveryReport, error) {
+	querier, ok := client.(StatusQuerier)
+	if !ok {
+		return nil, fmt.Errorf("client %T does not support status queries", client)
+	}
+
+	return querier.QueryStatus(ctx, query)
+}
@@ -0,0 +1,60 @@
+// Copyright 2024 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_sms_sender
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// statusClient is a fakeClient reporting every message as delivered.
+type statusClient struct {
+	fakeClient
+}
+
+func (c *statusClient) QueryStatus(ctx context.Context, query StatusQuery) (*DeliveryReport, error) {
+	return &DeliveryReport{Provider: "fake", MessageId: query.MessageId, Recipient: query.PhoneNumber, Status: DeliveryStatusDelivered}, nil
+}
+
+func TestQueryStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		client  SmsClient
+		wantErr bool
+	}{
+		{"querier", &statusClient{}, false},
+		{"retry", NewRetryClient(&statusClient{}, RetryPolicy{}), false},
+		{"circuit breaker", NewCircuitBreaker(&statusClient{}, CircuitBreakerPolicy{}), false},
+		{"rate limit", NewRateLimitClient(&statusClient{}, RateLimitPolicy{}), false},
+		{"opt-out", NewOptOutClient(&statusClient{}, OptOutPolicy{}), false},
+		{"nested wrappers", NewRetryClient(NewCircuitBreaker(&statusClient{}, CircuitBreakerPolicy{}), RetryPolicy{}), false},
+		{"not a querier", &fakeClient{}, true},
+		{"wrapped non querier", NewRetryClient(&fakeClient{}, RetryPolicy{}), true},
+	}
+
+	query := StatusQuery{MessageId: "SM123", PhoneNumber: "+12065550100", SentAt: time.Now()}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := QueryStatus(context.Background(), tt.client, query)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %t", err, tt.wantErr)
+			}
+			if !tt.wantErr && (report.MessageId != query.MessageId || report.Status != DeliveryStatusDelivered) {
+				t.Errorf("report = %+v, want SM123 delivered", report)
+			}
+		})
+	}
+}
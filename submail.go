@@ -16,25 +16,48 @@ package go_sms_sender
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"mime/multipart"
 	"net/http"
+	"strconv"
 	"strings"
 )
 
+const submailEndpoint = "https://api-v4.mysubmail.com"
+
 type SubmailClient struct {
-	api       string
+	endpoint  string
 	appid     string
 	signature string
 	project   string
+
+	httpClient *http.Client
 }
 
 type SubmailResult struct {
-	Status string `json:"status"`
-	Code   int    `json:"code"`
-	Msg    string `json:"msg"`
+	Status     string `json:"status"`
+	To         string `json:"to"`
+	SendId     string `json:"send_id"`
+	Fee        int    `json:"fee"`
+	SmsCredits string `json:"sms_credits"`
+	Code       int    `json:"code"`
+	Msg        string `json:"msg"`
+}
+
+// submailErrors maps the error codes of the Submail API.
+var submailErrors = map[string]error{
+	"101": ErrInvalidCredentials,
+	"102": ErrInvalidCredentials,
+	"103": ErrInvalidCredentials,
+	"104": ErrInvalidCredentials,
+	"105": ErrInvalidCredentials,
+	"106": ErrInvalidCredentials,
+	"109": ErrInvalidCredentials,
+	"251": ErrMissingParameter,
+	"252": ErrInvalidNumber,
 }
 
 func buildSubmailPostdata(param map[string]string, appid string, signature string, project string, targetPhoneNumber []string) (map[string]string, error) {
@@ -60,20 +83,50 @@ func buildSubmailPostdata(param map[string]string, appid string, signature strin
 	return postdata, nil
 }
 
+func init() {
+	Register(SUBMAIL, func(config *Config) (SmsClient, error) {
+		return GetSubmailClient(config.AccessId, config.AccessKey, config.Template)
+	},
+		requiredField("accessId", "App ID"),
+		requiredField("accessKey", "App Key"),
+		requiredField("template", "Project ID"),
+		optionalField("endpoint", "Endpoint"),
+	)
+}
+
 func GetSubmailClient(appid string, signature string, project string) (*SubmailClient, error) {
 	submailClient := &SubmailClient{
-		api:       "https://api-v4.mysubmail.com/sms/multixsend",
+		endpoint:  submailEndpoint,
 		appid:     appid,
 		signature: signature,
 		project:   project,
+
+		httpClient: newDefaultHttpClient(),
 	}
 	return submailClient, nil
 }
 
+func (c *SubmailClient) SetHttpClient(httpClient *http.Client) {
+	c.httpClient = httpClient
+}
+
+func (c *SubmailClient) SetEndpoint(endpoint string) {
+	c.endpoint = strings.TrimSuffix(endpoint, "/")
+}
+
 func (c *SubmailClient) SendMessage(param map[string]string, targetPhoneNumber ...string) error {
+	return c.SendMessageContext(context.Background(), param, targetPhoneNumber...)
+}
+
+func (c *SubmailClient) SendMessageContext(ctx context.Context, param map[string]string, targetPhoneNumber ...string) error {
+	_, err := c.SendMessageResult(ctx, param, targetPhoneNumber...)
+	return err
+}
+
+func (c *SubmailClient) SendMessageResult(ctx context.Context, param map[string]string, targetPhoneNumber ...string) (*SendResult, error) {
 	postdata, err := buildSubmailPostdata(param, c.appid, c.signature, c.project, targetPhoneNumber)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	body := &bytes.Buffer{}
@@ -81,30 +134,39 @@ func (c *SubmailClient) SendMessage(param map[string]string, targetPhoneNumber .
 	for key, val := range postdata {
 		err = writer.WriteField(key, val)
 		if err != nil {
-			return err
+			return nil, err
 		}
 	}
 
 	contentType := writer.FormDataContentType()
 	err = writer.Close()
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.endpoint+"/sms/multixsend", body)
+	if err != nil {
+		return nil, err
 	}
+	req.Header.Set("Content-Type", contentType)
 
-	resp, err := http.Post(c.api, contentType, body)
+	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	defer resp.Body.Close()
 
 	result, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return handleSubmailResult(result)
+	sendResult := newSendResult(SUBMAIL)
+	err = handleSubmailResult(result, sendResult)
+	return sendResult, err
 }
 
-func handleSubmailResult(result []byte) error {
+func handleSubmailResult(result []byte, sendResult *SendResult) error {
 	var submailSuccessResult []SubmailResult
 	err := json.Unmarshal(result, &submailSuccessResult)
 	if err != nil {
@@ -115,21 +177,97 @@ func handleSubmailResult(result []byte) error {
 		}
 
 		if submailErrorResult.Msg != "" {
-			return fmt.Errorf(submailErrorResult.Msg)
+			return newSmsError(SUBMAIL, strconv.Itoa(submailErrorResult.Code), submailErrorResult.Msg, submailErrors)
 		}
 	}
 
 	errMsgs := []string{}
+	errCode := ""
 	for _, submailResult := range submailSuccessResult {
+		status := SendStatusAccepted
+		if submailResult.Status != "success" {
+			status = SendStatusRejected
+		}
+
+		recipient := sendResult.add(submailResult.To, submailResult.SendId, status)
+		recipient.Code = strconv.Itoa(submailResult.Code)
+		recipient.Message = submailResult.Msg
+		recipient.Segments = submailResult.Fee
+
 		if submailResult.Status != "success" {
 			errMsg := fmt.Sprintf("%s, %d, %s", submailResult.Status, submailResult.Code, submailResult.Msg)
 			errMsgs = append(errMsgs, errMsg)
+			if errCode == "" {
+				errCode = strconv.Itoa(submailResult.Code)
+			}
 		}
 	}
 
 	if len(errMsgs) > 0 {
-		return fmt.Errorf(strings.Join(errMsgs, "|"))
+		return newSmsError(SUBMAIL, errCode, strings.Join(errMsgs, "|"), submailErrors)
 	}
 
 	return nil
 }
+
+// SubmailBalanceResult is the balance/sms response, the general and
+// transactional message counts left.
+type SubmailBalanceResult struct {
+	Status               string      `json:"status"`
+	Balance              json.Number `json:"balance"`
+	TransactionalBalance json.Number `json:"transactional_balance"`
+	Code                 int         `json:"code"`
+	Msg                  string      `json:"msg"`
+}
+
+func (c *SubmailClient) QueryBalance(ctx context.Context) (*Balance, error) {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	for key, val := range map[string]string{"appid": c.appid, "signature": c.signature} {
+		if err := writer.WriteField(key, val); err != nil {
+			return nil, err
+		}
+	}
+
+	contentType := writer.FormDataContentType()
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.endpoint+"/balance/sms", body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := readResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	var balanceResult SubmailBalanceResult
+	if err = json.Unmarshal(respBody, &balanceResult); err != nil {
+		return nil, err
+	}
+	if balanceResult.Status != "success" {
+		return nil, newSmsError(SUBMAIL, strconv.Itoa(balanceResult.Code), balanceResult.Msg, submailErrors)
+	}
+
+	remaining := 0
+	for _, count := range []json.Number{balanceResult.Balance, balanceResult.TransactionalBalance} {
+		if count == "" {
+			continue
+		}
+		n, err := parseMessageCount(count.String())
+		if err != nil {
+			return nil, err
+		}
+		remaining += n
+	}
+
+	return newMessageBalance(SUBMAIL, remaining), nil
+}
@@ -0,0 +1,125 @@
+// Copyright 2024 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_sms_sender
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/casdoor/go-sms-sender/phone"
+)
+
+var placeholderRegexp = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_.-]+)\s*\}\}`)
+
+// MessageTemplate is the message body of the providers sending plain text,
+// such as Twilio, Amazon SNS, Infobip, SmsBao and Huyi. Text uses named
+// placeholders such as {{code}}, {{minutes}} or {{app}}, filled from the
+// params, or a single %s replaced by param["code"].
+//
+// Locales holds translations of Text keyed by locale, such as "zh-CN" or "tr",
+// or by region, such as "CN". The variant is chosen by param["locale"], then
+// its language, then the region of the recipient's number.
+type MessageTemplate struct {
+	Text    string
+	Locales map[string]string
+}
+
+// TemplateSetter is implemented by the clients whose message body is a MessageTemplate.
+type TemplateSetter interface {
+	SetTemplate(template *MessageTemplate)
+}
+
+func NewMessageTemplate(text string) *MessageTemplate {
+	return &MessageTemplate{Text: text}
+}
+
+// Render returns the body for phoneNumber, or an ErrMissingParameter error
+// naming the placeholders param does not supply.
+func (t *MessageTemplate) Render(param map[string]string, phoneNumber string) (string, error) {
+	text := t.text(param["locale"], phoneNumber)
+
+	if !placeholderRegexp.MatchString(text) {
+		if !strings.Contains(text, "%s") {
+			return text, nil
+		}
+
+		code, ok := param["code"]
+		if !ok {
+			return "", missingParameterError("code")
+		}
+		return fmt.Sprintf(text, code), nil
+	}
+
+	missing := []string{}
+	seen := map[string]bool{}
+	body := placeholderRegexp.ReplaceAllStringFunc(text, func(placeholder string) string {
+		name := placeholderRegexp.FindStringSubmatch(placeholder)[1]
+		value, ok := param[name]
+		if !ok && !seen[name] {
+			seen[name] = true
+			missing = append(missing, name)
+		}
+		return value
+	})
+
+	if len(missing) > 0 {
+		return "", missingParameterError(strings.Join(missing, ", "))
+	}
+	return body, nil
+}
+
+func (t *MessageTemplate) text(locale string, phoneNumber string) string {
+	if len(t.Locales) == 0 {
+		return t.Text
+	}
+
+	if locale != "" {
+		if text, ok := t.Locales[locale]; ok {
+			return text
+		}
+		if i := strings.IndexAny(locale, "-_"); i > 0 {
+			if text, ok := t.Locales[locale[:i]]; ok {
+				return text
+			}
+		}
+	}
+
+	if number, err := phone.Parse(phoneNumber, ""); err == nil && number.Region != "" {
+		if text, ok := t.Locales[number.Region]; ok {
+			return text
+		}
+	}
+
+	return t.Text
+}
+
+// renderBodies renders the body of every target number and applies the
+// segment policy, so that a missing placeholder or a body too long fails the
+// send before any message goes out.
+func renderBodies(template *MessageTemplate, policy SegmentPolicy, param map[string]string, targetPhoneNumber []string) ([]string, error) {
+	bodies := make([]string, len(targetPhoneNumber))
+	for i, phoneNumber := range targetPhoneNumber {
+		body, err := template.Render(param, phoneNumber)
+		if err != nil {
+			return nil, err
+		}
+		bodies[i], err = policy.Apply(body)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return bodies, nil
+}
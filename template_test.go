@@ -0,0 +1,124 @@
+// Copyright 2024 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_sms_sender
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMessageTemplateRender(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		param   map[string]string
+		want    string
+		wantErr string
+	}{
+		{"plain text", "Welcome", nil, "Welcome", ""},
+		{"code verb", "Your code is %s", map[string]string{"code": "123456"}, "Your code is 123456", ""},
+		{"code verb without code", "Your code is %s", map[string]string{}, "", "code"},
+		{"escaped percent with code verb", "Code %s, 100%% free", map[string]string{"code": "123456"}, "Code 123456, 100% free", ""},
+		{"named placeholders", "{{code}} expires in {{ minutes }} minutes", map[string]string{"code": "123456", "minutes": "5"}, "123456 expires in 5 minutes", ""},
+		{"repeated placeholder", "{{code}}, again {{code}}", map[string]string{"code": "123456"}, "123456, again 123456", ""},
+		{"missing placeholders listed once", "{{app}}: {{code}} in {{minutes}}, {{app}}", map[string]string{"code": "123456"}, "", "app, minutes"},
+		{"percent kept with placeholders", "{{code}} is 100% yours", map[string]string{"code": "123456"}, "123456 is 100% yours", ""},
+		{"values are not expanded", "{{app}}: {{code}}", map[string]string{"app": "{{code}} %s", "code": "123456"}, "{{code}} %s: 123456", ""},
+		{"unknown braces kept", "{code} {{ bad name }}", nil, "{code} {{ bad name }}", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewMessageTemplate(tt.text).Render(tt.param, "+8613800138000")
+			if tt.wantErr != "" {
+				if !errors.Is(err, ErrMissingParameter) || !strings.HasSuffix(err.Error(), ": "+tt.wantErr) {
+					t.Fatalf("Render() error = %v, want ErrMissingParameter for %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Render() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMessageTemplateLocales(t *testing.T) {
+	template := &MessageTemplate{
+		Text: "Your code is {{code}}",
+		Locales: map[string]string{
+			"zh-CN": "您的验证码是{{code}}",
+			"tr":    "Doğrulama kodunuz {{code}}",
+			"CN":    "验证码{{code}}",
+		},
+	}
+
+	tests := []struct {
+		name        string
+		locale      string
+		phoneNumber string
+		want        string
+	}{
+		{"exact locale", "zh-CN", "+14155550100", "您的验证码是123456"},
+		{"language of locale", "tr-TR", "+14155550100", "Doğrulama kodunuz 123456"},
+		{"language with underscore", "tr_TR", "+14155550100", "Doğrulama kodunuz 123456"},
+		{"region of number", "", "+8613800138000", "验证码123456"},
+		{"unknown locale falls back to region", "fr", "+8613800138000", "验证码123456"},
+		{"fallback to text", "fr", "+14155550100", "Your code is 123456"},
+		{"unparsable number", "", "12345", "Your code is 123456"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			param := map[string]string{"code": "123456"}
+			if tt.locale != "" {
+				param["locale"] = tt.locale
+			}
+			got, err := template.Render(param, tt.phoneNumber)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Render() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderBodies(t *testing.T) {
+	template := &MessageTemplate{
+		Text:    "It’s {{code}}",
+		Locales: map[string]string{"TR": "{{code}} kodunuz {{app}}"},
+	}
+	param := map[string]string{"code": "123456"}
+
+	_, err := renderBodies(template, SegmentPolicy{}, param, []string{"+14155550100", "+905321234567"})
+	if !errors.Is(err, ErrMissingParameter) {
+		t.Errorf("error = %v, want ErrMissingParameter for the Turkish recipient", err)
+	}
+
+	bodies, err := renderBodies(template, SegmentPolicy{Transliterate: true}, param, []string{"+14155550100", "+8613800138000"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"It's 123456", "It's 123456"}; !reflect.DeepEqual(bodies, want) {
+		t.Errorf("bodies = %q, want %q", bodies, want)
+	}
+}
@@ -15,31 +15,77 @@
 package go_sms_sender
 
 import (
+	"context"
 	"fmt"
+	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/errors"
 	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/profile"
 	sms "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/sms/v20210111"
 )
 
+const tencentRegion = "ap-guangzhou"
+
 type TencentClient struct {
 	core     *sms.Client
+	profile  *profile.ClientProfile
 	appId    string
 	sign     string
 	template string
 }
 
-func GetTencentClient(accessId string, accessKey string, sign string, templateId string, appId []string) (*TencentClient, error) {
-	if len(appId) == 0 {
-		return nil, fmt.Errorf("missing parameter: appId")
+// tencentErrors maps the error code families of the Tencent Cloud SendSms API.
+var tencentErrors = map[string]error{
+	"AuthFailure":           ErrInvalidCredentials,
+	"UnauthorizedOperation": ErrInvalidCredentials,
+	"InvalidParameterValue.IncorrectPhoneNumber":                ErrInvalidNumber,
+	"UnsupportedOperation.UnsupportedRegion":                    ErrInvalidNumber,
+	"FailedOperation.PhoneNumberInBlacklist":                    ErrInvalidNumber,
+	"LimitExceeded":                                             ErrRateLimited,
+	"RequestLimitExceeded":                                      ErrRateLimited,
+	"FailedOperation.ContainSensitiveWord":                      ErrContentRejected,
+	"FailedOperation.SignatureIncorrectOrUnapproved":            ErrContentRejected,
+	"FailedOperation.TemplateIncorrectOrUnapproved":             ErrContentRejected,
+	"InvalidParameterValue.ProhibitedUseUrlInTemplateParameter": ErrContentRejected,
+	"InvalidParameterValue.TemplateParameterLengthLimit":        ErrContentRejected,
+	"FailedOperation.InsufficientBalanceInSmsPackage":           ErrInsufficientBalance,
+	"InternalError":    ErrProviderUnavailable,
+	"MissingParameter": ErrMissingParameter,
+	"InvalidParameterValue.TemplateParameterFormatError": ErrMissingParameter,
+}
+
+func init() {
+	Register(TencentCloud, func(config *Config) (SmsClient, error) {
+		return GetTencentClient(config.AccessId, config.AccessKey, config.Sign, config.Template, []string{config.AppId, config.Region})
+	},
+		requiredField("accessId", "Secret ID"),
+		requiredField("accessKey", "Secret Key"),
+		requiredField("sign", "Sign Name"),
+		requiredField("template", "Template ID"),
+		otherField("appId", "SDK App ID", true),
+		otherField("region", "Region", false),
+		optionalField("endpoint", "Endpoint"),
+	)
+}
+
+func GetTencentClient(accessId string, accessKey string, sign string, templateId string, other []string) (*TencentClient, error) {
+	if len(other) == 0 {
+		return nil, missingParameterError("appId")
 	}
 
 	credential := common.NewCredential(accessId, accessKey)
 	config := profile.NewClientProfile()
 	config.HttpProfile.ReqMethod = "POST"
 
-	region := "ap-guangzhou"
+	region := tencentRegion
+	if len(other) > 1 && other[1] != "" {
+		region = other[1]
+	}
+
 	client, err := sms.NewClient(credential, region, config)
 	if err != nil {
 		return nil, err
@@ -47,7 +93,8 @@ func GetTencentClient(accessId string, accessKey string, sign string, templateId
 
 	tencentClient := &TencentClient{
 		core:     client,
-		appId:    appId[0],
+		profile:  config,
+		appId:    other[0],
 		sign:     sign,
 		template: templateId,
 	}
@@ -55,9 +102,26 @@ func GetTencentClient(accessId string, accessKey string, sign string, templateId
 	return tencentClient, nil
 }
 
+func (c *TencentClient) SetHttpClient(httpClient *http.Client) {
+	c.core.WithHttpTransport(transportOf(httpClient))
+}
+
+func (c *TencentClient) SetEndpoint(endpoint string) {
+	c.profile.HttpProfile.Scheme, c.profile.HttpProfile.Endpoint = splitEndpoint(endpoint)
+}
+
 func (c *TencentClient) SendMessage(param map[string]string, targetPhoneNumber ...string) error {
+	return c.SendMessageContext(context.Background(), param, targetPhoneNumber...)
+}
+
+func (c *TencentClient) SendMessageContext(ctx context.Context, param map[string]string, targetPhoneNumber ...string) error {
+	_, err := c.SendMessageResult(ctx, param, targetPhoneNumber...)
+	return err
+}
+
+func (c *TencentClient) SendMessageResult(ctx context.Context, param map[string]string, targetPhoneNumber ...string) (*SendResult, error) {
 	if len(targetPhoneNumber) == 0 {
-		return fmt.Errorf("missing parameter: targetPhoneNumber")
+		return nil, missingParameterError("targetPhoneNumber")
 	}
 
 	var paramArray []string
@@ -78,12 +142,113 @@ func (c *TencentClient) SendMessage(param map[string]string, targetPhoneNumber .
 	request.TemplateId = common.StringPtr(c.template)
 	request.PhoneNumberSet = common.StringPtrs(targetPhoneNumber)
 
-	response, err := c.core.SendSms(request)
+	response, err := c.core.SendSmsWithContext(ctx, request)
 	if err != nil {
-		return err
+		if sdkErr, ok := err.(*errors.TencentCloudSDKError); ok {
+			return nil, newSmsErrorByPrefix(TencentCloud, sdkErr.GetCode(), sdkErr.GetMessage(), tencentErrors)
+		}
+		return nil, err
 	}
-	if len(response.Response.SendStatusSet) > 0 && response.Response.SendStatusSet[0].Code != nil && *response.Response.SendStatusSet[0].Code != "Ok" {
-		return fmt.Errorf(*response.Response.SendStatusSet[0].Message)
+
+	result := newSendResult(TencentCloud)
+	if response.Response.RequestId != nil {
+		result.RequestId = *response.Response.RequestId
 	}
-	return err
+	errMsgs := []string{}
+	errCode := ""
+	for _, sendStatus := range response.Response.SendStatusSet {
+		status := SendStatusAccepted
+		if sendStatus.Code == nil || *sendStatus.Code != "Ok" {
+			status = SendStatusRejected
+			errMsgs = append(errMsgs, fmt.Sprintf("%s: %s", stringValue(sendStatus.PhoneNumber), stringValue(sendStatus.Message)))
+			if errCode == "" {
+				errCode = stringValue(sendStatus.Code)
+			}
+		}
+
+		recipient := result.add(stringValue(sendStatus.PhoneNumber), stringValue(sendStatus.SerialNo), status)
+		recipient.Code = stringValue(sendStatus.Code)
+		recipient.Message = stringValue(sendStatus.Message)
+		if sendStatus.Fee != nil {
+			recipient.Segments = int(*sendStatus.Fee)
+		}
+	}
+
+	if len(errMsgs) > 0 {
+		return result, newSmsErrorByPrefix(TencentCloud, errCode, strings.Join(errMsgs, "|"), tencentErrors)
+	}
+	return result, nil
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// QueryStatus looks the message up among the statuses of its phone number
+// with PullSmsSendStatusByPhoneNumber, since query.SentAt or the last 24
+// hours. A message without a receipt yet is reported as pending.
+func (c *TencentClient) QueryStatus(ctx context.Context, query StatusQuery) (*DeliveryReport, error) {
+	if query.MessageId == "" || query.PhoneNumber == "" {
+		return nil, missingParameterError("messageId or phoneNumber")
+	}
+
+	begin := time.Now().Add(-24 * time.Hour)
+	if !query.SentAt.IsZero() {
+		begin = query.SentAt.Add(-time.Minute)
+	}
+
+	const limit = 100
+	for offset := uint64(0); ; offset += limit {
+		request := sms.NewPullSmsSendStatusByPhoneNumberRequest()
+		request.SmsSdkAppId = common.StringPtr(c.appId)
+		request.PhoneNumber = common.StringPtr(query.PhoneNumber)
+		request.BeginTime = common.Uint64Ptr(uint64(begin.Unix()))
+		request.Offset = common.Uint64Ptr(offset)
+		request.Limit = common.Uint64Ptr(limit)
+
+		response, err := c.core.PullSmsSendStatusByPhoneNumberWithContext(ctx, request)
+		if err != nil {
+			if sdkErr, ok := err.(*errors.TencentCloudSDKError); ok {
+				return nil, newSmsErrorByPrefix(TencentCloud, sdkErr.GetCode(), sdkErr.GetMessage(), tencentErrors)
+			}
+			return nil, err
+		}
+
+		statuses := response.Response.PullSmsSendStatusSet
+		for _, status := range statuses {
+			if stringValue(status.SerialNo) != query.MessageId {
+				continue
+			}
+
+			report := &DeliveryReport{
+				Provider:     TencentCloud,
+				MessageId:    query.MessageId,
+				Recipient:    stringValue(status.PhoneNumber),
+				Status:       DeliveryStatusDelivered,
+				ErrorMessage: stringValue(status.Description),
+			}
+			if stringValue(status.ReportStatus) != "SUCCESS" {
+				report.Status = DeliveryStatusFailed
+				report.ErrorCode = stringValue(status.ReportStatus)
+			}
+			if status.UserReceiveTime != nil {
+				report.Timestamp = time.Unix(int64(*status.UserReceiveTime), 0)
+			}
+			return report, nil
+		}
+
+		if len(statuses) < limit {
+			break
+		}
+	}
+
+	return &DeliveryReport{
+		Provider:  TencentCloud,
+		MessageId: query.MessageId,
+		Recipient: query.PhoneNumber,
+		Status:    DeliveryStatusPending,
+	}, nil
 }
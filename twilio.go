@@ -15,17 +15,60 @@
 package go_sms_sender
 
 import (
-	"fmt"
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/casdoor/go-sms-sender/internal/providerdata"
 	"github.com/twilio/twilio-go"
+	"github.com/twilio/twilio-go/client"
 	openapi "github.com/twilio/twilio-go/rest/api/v2010"
 )
 
+// TwilioClient sends from the number set with SetSender. Without one, the
+// first target phone number is used as the sender.
 type TwilioClient struct {
-	template string
+	template *MessageTemplate
+	segments SegmentPolicy
+	sender   string
 	core     *twilio.RestClient
 }
 
+// twilioErrors maps the Twilio REST API error codes.
+var twilioErrors = map[string]error{
+	"20003": ErrInvalidCredentials,
+	"20429": ErrRateLimited,
+	"21211": ErrInvalidNumber,
+	"21212": ErrInvalidNumber,
+	"21408": ErrInvalidNumber,
+	"21614": ErrInvalidNumber,
+	"21617": ErrContentRejected,
+	"30007": ErrContentRejected,
+	"21604": ErrMissingParameter,
+	"21602": ErrMissingParameter,
+}
+
+func init() {
+	Register(Twilio, func(config *Config) (SmsClient, error) {
+		client, err := GetTwilioClient(config.AccessId, config.AccessKey, config.Template)
+		if err != nil {
+			return nil, err
+		}
+
+		client.SetSender(config.Sender)
+		return client, nil
+	},
+		requiredField("accessId", "Account SID"),
+		requiredField("accessKey", "Auth Token"),
+		requiredField("template", "Template"),
+		optionalField("sender", "From Number"),
+		optionalField("endpoint", "Endpoint"),
+	)
+}
+
 func GetTwilioClient(accessId string, accessKey string, template string) (*TwilioClient, error) {
 	client := twilio.NewRestClientWithParams(twilio.ClientParams{
 		Username: accessId,
@@ -34,36 +77,189 @@ func GetTwilioClient(accessId string, accessKey string, template string) (*Twili
 
 	twilioClient := &TwilioClient{
 		core:     client,
-		template: template,
+		template: NewMessageTemplate(template),
 	}
 
 	return twilioClient, nil
 }
 
+func (c *TwilioClient) SetSender(sender string) {
+	c.sender = sender
+}
+
+// twilioEndpointClient sends the requests of the Twilio SDK to another host.
+type twilioEndpointClient struct {
+	client.BaseClient
+	scheme string
+	host   string
+}
+
+func (c *twilioEndpointClient) SendRequest(method string, rawURL string, data url.Values, headers map[string]interface{}) (*http.Response, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	u.Scheme = c.scheme
+	u.Host = c.host
+	return c.BaseClient.SendRequest(method, u.String(), data, headers)
+}
+
+func (c *TwilioClient) baseClient() client.BaseClient {
+	if endpointClient, ok := c.core.Client.(*twilioEndpointClient); ok {
+		return endpointClient.BaseClient
+	}
+	return c.core.Client
+}
+
+func (c *TwilioClient) SetHttpClient(httpClient *http.Client) {
+	if baseClient, ok := c.baseClient().(*client.Client); ok {
+		baseClient.HTTPClient = httpClient
+	}
+}
+
+func (c *TwilioClient) SetTemplate(template *MessageTemplate) {
+	c.template = template
+}
+
+func (c *TwilioClient) SetSegmentPolicy(policy SegmentPolicy) {
+	c.segments = policy
+}
+
+func (c *TwilioClient) SetEndpoint(endpoint string) {
+	scheme, host := splitEndpoint(endpoint)
+	c.core.Client = &twilioEndpointClient{
+		BaseClient: c.baseClient(),
+		scheme:     scheme,
+		host:       host,
+	}
+}
+
 // SendMessage targetPhoneNumber[0] is the sender's number, so targetPhoneNumber should have at least two parameters
 func (c *TwilioClient) SendMessage(param map[string]string, targetPhoneNumber ...string) error {
-	code, ok := param["code"]
-	if !ok {
-		return fmt.Errorf("missing parameter: code")
+	return c.SendMessageContext(context.Background(), param, targetPhoneNumber...)
+}
+
+func (c *TwilioClient) SendMessageContext(ctx context.Context, param map[string]string, targetPhoneNumber ...string) error {
+	_, err := c.SendMessageResult(ctx, param, targetPhoneNumber...)
+	return err
+}
+
+func (c *TwilioClient) SendMessageResult(ctx context.Context, param map[string]string, targetPhoneNumber ...string) (*SendResult, error) {
+	from := c.sender
+	if from == "" {
+		if len(targetPhoneNumber) == 0 {
+			return nil, missingParameterError("targetPhoneNumber")
+		}
+		from, targetPhoneNumber = targetPhoneNumber[0], targetPhoneNumber[1:]
 	}
 
-	bodyContent := fmt.Sprintf(c.template, code)
+	if len(targetPhoneNumber) == 0 {
+		return nil, missingParameterError("targetPhoneNumber")
+	}
 
-	if len(targetPhoneNumber) < 2 {
-		return fmt.Errorf("bad parameter: targetPhoneNumber")
+	bodies, err := renderBodies(c.template, c.segments, param, targetPhoneNumber)
+	if err != nil {
+		return nil, err
 	}
 
 	params := &openapi.CreateMessageParams{}
-	params.SetFrom(targetPhoneNumber[0])
-	params.SetBody(bodyContent)
+	params.SetFrom(from)
 
-	for i := 1; i < len(targetPhoneNumber); i++ {
+	result := newSendResult(Twilio)
+	for i := 0; i < len(targetPhoneNumber); i++ {
 		params.SetTo(targetPhoneNumber[i])
-		_, err := c.core.Api.CreateMessage(params)
-		if err != nil {
+		params.SetBody(bodies[i])
+
+		var message *openapi.ApiV2010Message
+		err := runWithContext(ctx, func() error {
+			var err error
+			message, err = c.core.Api.CreateMessage(params)
 			return err
+		})
+		if err != nil {
+			if restErr, ok := err.(*client.TwilioRestError); ok {
+				err = newSmsError(Twilio, strconv.Itoa(restErr.Code), restErr.Message, twilioErrors)
+			}
+			result.add(targetPhoneNumber[i], "", SendStatusRejected).Message = err.Error()
+			return result, err
+		}
+
+		recipient := result.add(targetPhoneNumber[i], stringValue(message.Sid), SendStatusAccepted)
+		recipient.Code = stringValue(message.Status)
+		if message.Price != nil {
+			recipient.Fee = strings.TrimSpace(*message.Price + " " + stringValue(message.PriceUnit))
+		}
+		if message.NumSegments != nil {
+			recipient.Segments, _ = strconv.Atoi(*message.NumSegments)
+		}
+	}
+
+	return result, nil
+}
+
+// QueryStatus fetches the message by its SID.
+func (c *TwilioClient) QueryStatus(ctx context.Context, query StatusQuery) (*DeliveryReport, error) {
+	if query.MessageId == "" {
+		return nil, missingParameterError("messageId")
+	}
+
+	var message *openapi.ApiV2010Message
+	err := runWithContext(ctx, func() error {
+		var err error
+		message, err = c.core.Api.FetchMessage(query.MessageId, &openapi.FetchMessageParams{})
+		return err
+	})
+	if err != nil {
+		if restErr, ok := err.(*client.TwilioRestError); ok {
+			if restErr.Status == http.StatusNotFound {
+				return nil, ErrMessageNotFound
+			}
+			err = newSmsError(Twilio, strconv.Itoa(restErr.Code), restErr.Message, twilioErrors)
+		}
+		return nil, err
+	}
+
+	status, ok := providerdata.TwilioStatuses[stringValue(message.Status)]
+	if !ok {
+		status = string(DeliveryStatusUnknown)
+	}
+
+	report := &DeliveryReport{
+		Provider:     Twilio,
+		MessageId:    query.MessageId,
+		Recipient:    stringValue(message.To),
+		Status:       DeliveryStatus(status),
+		ErrorMessage: stringValue(message.ErrorMessage),
+	}
+	if message.ErrorCode != nil && *message.ErrorCode != 0 {
+		report.ErrorCode = strconv.Itoa(*message.ErrorCode)
+	}
+	if updatedAt, err := time.Parse(time.RFC1123Z, stringValue(message.DateUpdated)); err == nil {
+		report.Timestamp = updatedAt
+	}
+
+	return report, nil
+}
+
+func (c *TwilioClient) QueryBalance(ctx context.Context) (*Balance, error) {
+	var balance *openapi.ApiV2010Balance
+	err := runWithContext(ctx, func() error {
+		var err error
+		balance, err = c.core.Api.FetchBalance(&openapi.FetchBalanceParams{})
+		return err
+	})
+	if err != nil {
+		if restErr, ok := err.(*client.TwilioRestError); ok {
+			err = newSmsError(Twilio, strconv.Itoa(restErr.Code), restErr.Message, twilioErrors)
 		}
+		return nil, err
+	}
+
+	amount, err := strconv.ParseFloat(stringValue(balance.Balance), 64)
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	return newAmountBalance(Twilio, amount, stringValue(balance.Currency)), nil
 }
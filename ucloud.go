@@ -15,12 +15,15 @@
 package go_sms_sender
 
 import (
-	"fmt"
+	"context"
+	"net/http"
+	"strconv"
 
 	"github.com/ucloud/ucloud-sdk-go/services/usms"
 	"github.com/ucloud/ucloud-sdk-go/ucloud"
 	"github.com/ucloud/ucloud-sdk-go/ucloud/auth"
 	"github.com/ucloud/ucloud-sdk-go/ucloud/config"
+	uerr "github.com/ucloud/ucloud-sdk-go/ucloud/error"
 )
 
 type UcloudClient struct {
@@ -32,9 +35,31 @@ type UcloudClient struct {
 	Template   string
 }
 
+// ucloudErrors maps the RetCode values of the UCloud API gateway.
+var ucloudErrors = map[string]error{
+	"150": ErrProviderUnavailable,
+	"161": ErrMissingParameter,
+	"171": ErrInvalidCredentials,
+	"172": ErrInvalidCredentials,
+	"230": ErrMissingParameter,
+}
+
+func init() {
+	Register(UCloud, func(config *Config) (SmsClient, error) {
+		return GetUcloudClient(config.AccessId, config.AccessKey, config.Sign, config.Template, []string{config.ProjectId})
+	},
+		requiredField("accessId", "Public Key"),
+		requiredField("accessKey", "Private Key"),
+		requiredField("sign", "Sign Name"),
+		requiredField("template", "Template ID"),
+		otherField("projectId", "Project ID", true),
+		optionalField("endpoint", "Endpoint"),
+	)
+}
+
 func GetUcloudClient(publicKey string, privateKey string, sign string, template string, projectId []string) (*UcloudClient, error) {
 	if len(projectId) == 0 {
-		return nil, fmt.Errorf("missing parameter: projectId")
+		return nil, missingParameterError("projectId")
 	}
 
 	cfg := config.NewConfig()
@@ -57,14 +82,32 @@ func GetUcloudClient(publicKey string, privateKey string, sign string, template
 	return ucloudClient, nil
 }
 
+func (c *UcloudClient) SetHttpClient(httpClient *http.Client) {
+	c.core.SetTransport(transportOf(httpClient))
+}
+
+func (c *UcloudClient) SetEndpoint(endpoint string) {
+	scheme, host := splitEndpoint(endpoint)
+	c.core.GetConfig().BaseUrl = scheme + "://" + host
+}
+
 func (c *UcloudClient) SendMessage(param map[string]string, targetPhoneNumber ...string) error {
+	return c.SendMessageContext(context.Background(), param, targetPhoneNumber...)
+}
+
+func (c *UcloudClient) SendMessageContext(ctx context.Context, param map[string]string, targetPhoneNumber ...string) error {
+	_, err := c.SendMessageResult(ctx, param, targetPhoneNumber...)
+	return err
+}
+
+func (c *UcloudClient) SendMessageResult(ctx context.Context, param map[string]string, targetPhoneNumber ...string) (*SendResult, error) {
 	code, ok := param["code"]
 	if !ok {
-		return fmt.Errorf("missing parameter: code")
+		return nil, missingParameterError("code")
 	}
 
 	if len(targetPhoneNumber) == 0 {
-		return fmt.Errorf("missing parameter: targetPhoneNumber")
+		return nil, missingParameterError("targetPhoneNumber")
 	}
 
 	req := c.core.NewSendUSMSMessageRequest()
@@ -72,12 +115,35 @@ func (c *UcloudClient) SendMessage(param map[string]string, targetPhoneNumber ..
 	req.TemplateId = ucloud.String(c.Template)
 	req.PhoneNumbers = targetPhoneNumber
 	req.TemplateParams = []string{code}
-	response, err := c.core.SendUSMSMessage(req)
-	if err != nil {
+	var response *usms.SendUSMSMessageResponse
+	err := runWithContext(ctx, func() error {
+		var err error
+		response, err = c.core.SendUSMSMessage(req)
 		return err
+	})
+	if err != nil {
+		if uerr.IsCodeError(err) {
+			codeErr := err.(uerr.Error)
+			return nil, newSmsError(UCloud, strconv.Itoa(codeErr.Code()), codeErr.Message(), ucloudErrors)
+		}
+		return nil, err
+	}
+
+	result := newSendResult(UCloud)
+	result.RequestId = response.GetRequestUUID()
+
+	status := SendStatusAccepted
+	if response.RetCode != 0 {
+		status = SendStatusRejected
 	}
+	for _, phoneNumber := range targetPhoneNumber {
+		recipient := result.add(phoneNumber, response.SessionNo, status)
+		recipient.Code = strconv.Itoa(response.RetCode)
+		recipient.Message = response.Message
+	}
+
 	if response.RetCode != 0 {
-		return fmt.Errorf(response.Message)
+		return result, newSmsError(UCloud, strconv.Itoa(response.RetCode), response.Message, ucloudErrors)
 	}
-	return nil
+	return result, nil
 }
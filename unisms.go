@@ -15,10 +15,11 @@
 package go_sms_sender
 
 import (
+	"context"
 	"errors"
-	"fmt"
 	"strings"
 
+	uni "github.com/apistd/uni-go-sdk"
 	unisms "github.com/apistd/uni-go-sdk/sms"
 )
 
@@ -28,18 +29,26 @@ type UnismsClient struct {
 	template string
 }
 
+func init() {
+	Register(UniSms, func(config *Config) (SmsClient, error) {
+		return GetUnismsClient(config.AccessId, config.AccessKey, config.Sign, config.Template)
+	},
+		requiredField("accessId", "Access Key ID"),
+		requiredField("accessKey", "Access Key Secret"),
+		requiredField("sign", "Signature"),
+		requiredField("template", "Template ID"),
+		optionalField("endpoint", "Endpoint"),
+	)
+}
+
+// unismsErrors maps the codes of the UniSms API.
+var unismsErrors = map[string]error{
+	"104111": ErrInvalidCredentials,
+}
+
 func GetUnismsClient(accessId string, accessKey string, signature string, templateId string) (*UnismsClient, error) {
 	client := unisms.NewClient(accessId, accessKey)
 
-	// Check the correctness of the accessId and accessKey
-	msg := unisms.BuildMessage()
-	msg.SetTo("test")
-	msg.SetTemplateId("pub_verif_register") // free template
-	_, err := client.Send(msg)
-	if strings.Contains(err.Error(), "[104111] InvalidAccessKeyId") {
-		return nil, err
-	}
-
 	unismsClient := &UnismsClient{
 		core:     client,
 		sign:     signature,
@@ -49,9 +58,22 @@ func GetUnismsClient(accessId string, accessKey string, signature string, templa
 	return unismsClient, nil
 }
 
+func (c *UnismsClient) SetEndpoint(endpoint string) {
+	c.core.Client.SetEndpoint(endpoint)
+}
+
 func (c *UnismsClient) SendMessage(param map[string]string, targetPhoneNumber ...string) error {
+	return c.SendMessageContext(context.Background(), param, targetPhoneNumber...)
+}
+
+func (c *UnismsClient) SendMessageContext(ctx context.Context, param map[string]string, targetPhoneNumber ...string) error {
+	_, err := c.SendMessageResult(ctx, param, targetPhoneNumber...)
+	return err
+}
+
+func (c *UnismsClient) SendMessageResult(ctx context.Context, param map[string]string, targetPhoneNumber ...string) (*SendResult, error) {
 	if len(targetPhoneNumber) == 0 {
-		return fmt.Errorf("missing parameter: targetPhoneNumber")
+		return nil, missingParameterError("targetPhoneNumber")
 	}
 
 	msg := unisms.BuildMessage()
@@ -59,14 +81,51 @@ func (c *UnismsClient) SendMessage(param map[string]string, targetPhoneNumber ..
 	msg.SetSignature(c.sign)
 	msg.SetTemplateId(c.template)
 
-	resp, err := c.core.Send(msg)
-	if err != nil {
+	var resp *uni.UniResponse
+	err := runWithContext(ctx, func() error {
+		var err error
+		resp, err = c.core.Send(msg)
 		return err
+	})
+	if err != nil {
+		return nil, getUnismsError(err)
+	}
+
+	result := newSendResult(UniSms)
+	result.RequestId = resp.RequestId
+
+	messages, _ := resp.Data["messages"].([]interface{})
+	for _, item := range messages {
+		message, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		to, _ := message["to"].(string)
+		id, _ := message["id"].(string)
+		recipient := result.add(to, id, SendStatusAccepted)
+		recipient.Code, _ = message["status"].(string)
+		recipient.Fee, _ = message["price"].(string)
+		if messageCount, ok := message["messageCount"].(float64); ok {
+			recipient.Segments = int(messageCount)
+		}
 	}
 
 	if resp.Code != "0" {
-		return errors.New(resp.Message)
+		return result, errors.New(resp.Message)
+	}
+
+	return result, nil
+}
+
+// getUnismsError classifies the errors of the SDK, which formats the API
+// errors as "[code] message, RequestId: id".
+func getUnismsError(err error) error {
+	message := err.Error()
+	end := strings.Index(message, "]")
+	if !strings.HasPrefix(message, "[") || end < 0 {
+		return err
 	}
 
-	return nil
+	return newSmsError(UniSms, message[1:end], strings.TrimSpace(message[end+1:]), unismsErrors)
 }
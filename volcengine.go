@@ -15,8 +15,10 @@
 package go_sms_sender
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"strings"
 
 	"github.com/volcengine/volc-sdk-golang/service/sms"
@@ -29,9 +31,38 @@ type VolcClient struct {
 	smsAccount string
 }
 
+// volcErrors maps the common error codes of the Volc Engine OpenAPI.
+var volcErrors = map[string]error{
+	"InvalidAccessKey":         ErrInvalidCredentials,
+	"InvalidCredential":        ErrInvalidCredentials,
+	"SignatureDoesNotMatch":    ErrInvalidCredentials,
+	"AccessDenied":             ErrInvalidCredentials,
+	"FlowLimitExceeded":        ErrRateLimited,
+	"AccountFlowLimitExceeded": ErrRateLimited,
+	"ServiceFlowLimitExceeded": ErrRateLimited,
+	"ServiceUnavailableTemp":   ErrProviderUnavailable,
+	"InternalError":            ErrProviderUnavailable,
+	"InternalServiceError":     ErrProviderUnavailable,
+	"InternalServiceTimeout":   ErrProviderUnavailable,
+	"MissingParameter":         ErrMissingParameter,
+}
+
+func init() {
+	Register(VolcEngine, func(config *Config) (SmsClient, error) {
+		return GetVolcClient(config.AccessId, config.AccessKey, config.Sign, config.Template, []string{config.SmsAccount})
+	},
+		requiredField("accessId", "Access Key ID"),
+		requiredField("accessKey", "Secret Access Key"),
+		requiredField("sign", "Sign Name"),
+		requiredField("template", "Template ID"),
+		otherField("smsAccount", "SMS Account", true),
+		optionalField("endpoint", "Endpoint"),
+	)
+}
+
 func GetVolcClient(accessId, accessKey, sign, templateId string, smsAccount []string) (*VolcClient, error) {
 	if len(smsAccount) == 0 {
-		return nil, fmt.Errorf("missing parameter: smsAccount")
+		return nil, missingParameterError("smsAccount")
 	}
 
 	client := sms.NewInstance()
@@ -48,14 +79,33 @@ func GetVolcClient(accessId, accessKey, sign, templateId string, smsAccount []st
 	return volcClient, nil
 }
 
+func (c *VolcClient) SetHttpClient(httpClient *http.Client) {
+	c.core.Client.Client = httpClient
+}
+
+func (c *VolcClient) SetEndpoint(endpoint string) {
+	scheme, host := splitEndpoint(endpoint)
+	c.core.SetSchema(scheme)
+	c.core.SetHost(host)
+}
+
 func (c *VolcClient) SendMessage(param map[string]string, targetPhoneNumber ...string) error {
+	return c.SendMessageContext(context.Background(), param, targetPhoneNumber...)
+}
+
+func (c *VolcClient) SendMessageContext(ctx context.Context, param map[string]string, targetPhoneNumber ...string) error {
+	_, err := c.SendMessageResult(ctx, param, targetPhoneNumber...)
+	return err
+}
+
+func (c *VolcClient) SendMessageResult(ctx context.Context, param map[string]string, targetPhoneNumber ...string) (*SendResult, error) {
 	if len(targetPhoneNumber) == 0 {
-		return fmt.Errorf("missing parameter: targetPhoneNumber")
+		return nil, missingParameterError("targetPhoneNumber")
 	}
 
 	requestParam, err := json.Marshal(param)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	req := &sms.SmsRequest{
@@ -66,16 +116,38 @@ func (c *VolcClient) SendMessage(param map[string]string, targetPhoneNumber ...s
 		PhoneNumbers:  strings.Join(targetPhoneNumber, ","),
 	}
 
-	resp, statusCode, err := c.core.Send(req)
+	var resp *sms.SmsResponse
+	var statusCode int
+	err = runWithContext(ctx, func() error {
+		var err error
+		resp, statusCode, err = c.core.Send(req)
+		return err
+	})
 	if err != nil {
-		return fmt.Errorf("send message failed, error: %q", err.Error())
+		return nil, fmt.Errorf("send message failed, error: %q", err.Error())
 	}
 	if statusCode < 200 || statusCode > 299 {
-		return fmt.Errorf("send message failed, statusCode: %d", statusCode)
+		return nil, fmt.Errorf("send message failed, statusCode: %d", statusCode)
 	}
+
+	result := newSendResult(VolcEngine)
+	result.RequestId = resp.ResponseMetadata.RequestId
 	if resp.ResponseMetadata.Error != nil {
-		return fmt.Errorf("send message failed, code: %q, message: %q", resp.ResponseMetadata.Error.Code, resp.ResponseMetadata.Error.Message)
+		for _, phoneNumber := range targetPhoneNumber {
+			recipient := result.add(phoneNumber, "", SendStatusRejected)
+			recipient.Code = resp.ResponseMetadata.Error.Code
+			recipient.Message = resp.ResponseMetadata.Error.Message
+		}
+		return result, newSmsError(VolcEngine, resp.ResponseMetadata.Error.Code, resp.ResponseMetadata.Error.Message, volcErrors)
+	}
+
+	for i, phoneNumber := range targetPhoneNumber {
+		messageId := ""
+		if resp.Result != nil && i < len(resp.Result.MessageID) {
+			messageId = resp.Result.MessageID[i]
+		}
+		result.add(phoneNumber, messageId, SendStatusAccepted)
 	}
 
-	return nil
+	return result, nil
 }